@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -53,11 +54,13 @@ func NormalizeSegmentEnabled(order []string, enabled []bool) []bool {
 
 // InputData is the JSON structure passed from Claude Code via stdin
 type InputData struct {
+	SessionID      string          `json:"session_id"`
 	Model          ModelInfo       `json:"model"`
 	Workspace      WorkspaceInfo   `json:"workspace"`
 	TranscriptPath string          `json:"transcript_path"`
 	Cost           CostInfo        `json:"cost"`
 	OutputStyle    OutputStyleInfo `json:"output_style"`
+	Version        string          `json:"version"` // Claude Code 版本
 }
 
 // ModelInfo contains model identification information
@@ -69,6 +72,7 @@ type ModelInfo struct {
 // WorkspaceInfo contains workspace directory information
 type WorkspaceInfo struct {
 	CurrentDir string `json:"current_dir"`
+	ProjectDir string `json:"project_dir"`
 }
 
 // CostInfo contains API cost information
@@ -90,6 +94,212 @@ type SimpleConfig struct {
 	// CCH Configuration
 	CCHApiKey string `toml:"cch_api_key"`
 	CCHURL    string `toml:"cch_url"`
+	// Segment Options
+	Git           GitOptions           `toml:"git"`
+	GitDiff       GitDiffOptions       `toml:"git_diff"`
+	ContextWindow ContextWindowOptions `toml:"context_window"`
+	ProgressBar   ProgressBarOptions   `toml:"progress_bar"`
+	ContextEta    ContextEtaOptions    `toml:"context_eta"`
+	Cost          CostOptions          `toml:"cost"`
+	Metrics       MetricsOptions       `toml:"metrics"`
+	Todos         TodosOptions         `toml:"todos"`
+	Tools         ToolsOptions         `toml:"tools"`
+	SessionTitle  SessionTitleOptions  `toml:"session_title"`
+	Files         FilesOptions         `toml:"files"`
+	APIErrors     APIErrorsOptions     `toml:"api_errors"`
+	CCVersion     CCVersionOptions     `toml:"cc_version"`
+	Model         ModelOptions         `toml:"model"`
+}
+
+// ModelOptions configures the model segment
+type ModelOptions struct {
+	ShowSwitches bool `toml:"show_switches"` // 显示会话内实际回答的模型的切换次数，需要解析整个 transcript
+}
+
+// GitOptions configures the git segment
+type GitOptions struct {
+	ShowWorktree   bool `toml:"show_worktree"`    // 在 linked worktree 中显示 worktree 名称
+	ShowSubmodule  bool `toml:"show_submodule"`   // 在 submodule 中显示所属的 superproject
+	ScanChildRepos bool `toml:"scan_child_repos"` // current_dir 不是仓库时汇总子目录中仓库的脏状态
+	// git status 结果的缓存秒数；index、HEAD 或仓库根目录变化时立即失效，
+	// 修改已跟踪文件的内容不会触发失效，最多延迟这么久才显示 *
+	StatusCacheSeconds int `toml:"status_cache_seconds"`
+}
+
+// DefaultGitOptions returns the default git segment options
+func DefaultGitOptions() GitOptions {
+	return GitOptions{
+		ShowWorktree:       true,
+		ShowSubmodule:      true,
+		StatusCacheSeconds: 10,
+	}
+}
+
+// GitDiffOptions configures the git_diff segment
+type GitDiffOptions struct {
+	BaseBranch string `toml:"base_branch"` // 对比的基准分支，留空时根据 origin/HEAD 自动识别
+}
+
+// ContextWindowOptions configures the context_window segment
+type ContextWindowOptions struct {
+	CompactAware         bool    `toml:"compact_aware"`          // 以自动压缩阈值而非上下文上限计算使用率
+	AutoCompactThreshold float64 `toml:"auto_compact_threshold"` // 自动压缩阈值，占上下文上限的百分比
+	ShowDelta            bool    `toml:"show_delta"`             // 显示本轮新增的 token，如 "+2.3K tok"
+}
+
+// DefaultContextWindowOptions returns the default context_window segment options
+func DefaultContextWindowOptions() ContextWindowOptions {
+	return ContextWindowOptions{
+		AutoCompactThreshold: 80,
+	}
+}
+
+// ContextEtaOptions configures the context_eta segment
+type ContextEtaOptions struct {
+	Window int `toml:"window"` // 平滑窗口，按最近 N 轮计算平均增长
+}
+
+// DefaultContextEtaOptions returns the default context_eta segment options
+func DefaultContextEtaOptions() ContextEtaOptions {
+	return ContextEtaOptions{
+		Window: 5,
+	}
+}
+
+// Cost sources
+const (
+	CostSourceAuto  = "auto"  // 优先使用 Claude Code 提供的 total_cost_usd，为 0 时按 transcript 本地计算
+	CostSourceInput = "input" // 仅使用 total_cost_usd
+	CostSourceLocal = "local" // 始终按 transcript usage 与价格表本地计算
+)
+
+// ModelPricing is the price of a model in USD per million tokens
+type ModelPricing struct {
+	Input        float64 `toml:"input"`
+	Output       float64 `toml:"output"`
+	CacheWrite5m float64 `toml:"cache_write_5m"` // 5 分钟缓存写入
+	CacheWrite1h float64 `toml:"cache_write_1h"` // 1 小时缓存写入
+	CacheRead    float64 `toml:"cache_read"`
+}
+
+// CostOptions configures the cost segment
+type CostOptions struct {
+	Source    string                  `toml:"source"`     // "auto"、"input" 或 "local"
+	ShowDelta bool                    `toml:"show_delta"` // 显示本轮新增的费用，如 "+$0.04"
+	Pricing   map[string]ModelPricing `toml:"pricing"`    // 按模型 ID 片段覆盖内置价格表，如 "claude-sonnet-4"
+}
+
+// DefaultCostOptions returns the default cost segment options
+func DefaultCostOptions() CostOptions {
+	return CostOptions{
+		Source: CostSourceAuto,
+	}
+}
+
+// MetricsOptions configures the Prometheus textfile export
+type MetricsOptions struct {
+	Enabled bool   `toml:"enabled"` // 每次渲染时写入指标文件
+	Path    string `toml:"path"`    // 指标文件路径，留空时为 ~/.claude/cchline/cchline.prom
+}
+
+// TodosOptions configures the todos segment
+type TodosOptions struct {
+	MaxWidth int `toml:"max_width"` // 进行中任务描述的最大显示宽度（单元格），0 表示不显示描述
+}
+
+// DefaultTodosOptions returns the default todos segment options
+func DefaultTodosOptions() TodosOptions {
+	return TodosOptions{
+		MaxWidth: 30,
+	}
+}
+
+// ToolsOptions configures the tools segment
+type ToolsOptions struct {
+	Tools         []string          `toml:"tools"`         // 统计与显示的工具，留空表示全部
+	Abbreviations map[string]string `toml:"abbreviations"` // 工具名缩写，如 { Bash = "$", Edit = "✎" }
+	MaxCounts     int               `toml:"max_counts"`    // 显示调用次数最多的前 N 个工具，0 表示不显示
+	TargetWidth   int               `toml:"target_width"`  // 工具目标的最大显示宽度（单元格）
+}
+
+// DefaultToolsOptions returns the default tools segment options
+func DefaultToolsOptions() ToolsOptions {
+	return ToolsOptions{
+		MaxCounts:   3,
+		TargetWidth: 20,
+	}
+}
+
+// SessionTitleOptions configures the session_title segment
+type SessionTitleOptions struct {
+	MaxWidth int `toml:"max_width"` // 标题的最大显示宽度（单元格）
+}
+
+// DefaultSessionTitleOptions returns the default session_title segment options
+func DefaultSessionTitleOptions() SessionTitleOptions {
+	return SessionTitleOptions{
+		MaxWidth: 30,
+	}
+}
+
+// FilesOptions configures the files segment
+type FilesOptions struct {
+	MarkUncommitted bool `toml:"mark_uncommitted"` // 根据 git status 标记仍未提交的文件
+}
+
+// APIErrorsOptions configures the api_errors segment
+type APIErrorsOptions struct {
+	QuietMinutes int `toml:"quiet_minutes"` // 最后一次错误后超过该分钟数不再显示
+}
+
+// DefaultAPIErrorsOptions returns the default api_errors segment options
+func DefaultAPIErrorsOptions() APIErrorsOptions {
+	return APIErrorsOptions{
+		QuietMinutes: 10,
+	}
+}
+
+// CCVersionOptions configures the cc_version segment
+type CCVersionOptions struct {
+	MinVersion string `toml:"min_version"` // 团队要求的最低 Claude Code 版本，如 "1.0.90"，低于该版本时告警
+}
+
+// Progress bar styles
+const (
+	ProgressBarStyleBlocks  = "blocks"  // ▰▰▰▱▱
+	ProgressBarStyleEighths = "eighths" // ███▍░ 以 1/8 格精度显示
+	ProgressBarStyleASCII   = "ascii"   // [###--]
+)
+
+// ProgressBarOptions configures progress bars for segments exposing a "percent" metadata value
+type ProgressBarOptions struct {
+	Segments          []string `toml:"segments"`           // 显示进度条的 segment，如 ["context_window", "cch_cost"]
+	Width             int      `toml:"width"`              // 进度条宽度（格数）
+	Style             string   `toml:"style"`              // "blocks"、"eighths" 或 "ascii"
+	Filled            string   `toml:"filled"`             // 自定义已填充字符，留空使用样式默认值
+	Empty             string   `toml:"empty"`              // 自定义未填充字符，留空使用样式默认值
+	WarnThreshold     float64  `toml:"warn_threshold"`     // 达到该百分比显示警告色
+	CriticalThreshold float64  `toml:"critical_threshold"` // 达到该百分比显示危险色
+}
+
+// DefaultProgressBarOptions returns the default progress bar options
+func DefaultProgressBarOptions() ProgressBarOptions {
+	return ProgressBarOptions{
+		Width:             10,
+		Style:             ProgressBarStyleBlocks,
+		WarnThreshold:     60,
+		CriticalThreshold: 85,
+	}
+}
+
+// Enabled reports whether the segment should render a progress bar
+func (o ProgressBarOptions) Enabled(id SegmentID) bool {
+	for _, name := range o.Segments {
+		if name == string(id) {
+			return true
+		}
+	}
+	return false
 }
 
 // SegmentToggles contains enable/disable flags for each segment
@@ -123,6 +333,22 @@ func NonBreakSegmentCount(order []string) int {
 	return count
 }
 
+// IsSegmentEnabled reports whether any instance of the named segment is enabled.
+func IsSegmentEnabled(order []string, enabled []bool, name string) bool {
+	enabled = NormalizeSegmentEnabled(order, enabled)
+	idx := 0
+	for _, s := range order {
+		if s == LineBreakMarker {
+			continue
+		}
+		if s == name && enabled[idx] {
+			return true
+		}
+		idx++
+	}
+	return false
+}
+
 // SegmentTogglePtr returns pointer to toggle field for a segment name.
 func SegmentTogglePtr(toggles *SegmentToggles, name string) *bool {
 	switch name {
@@ -175,6 +401,46 @@ func BuildSegmentEnabledFromToggles(order []string, toggles SegmentToggles) []bo
 	return enabled
 }
 
+// SettingsPath returns the path of the user's Claude settings.json
+func SettingsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".claude", "settings.json")
+}
+
+// ClaudeJSONPath returns the path of ~/.claude.json, where Claude Code keeps user and per-project state
+func ClaudeJSONPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".claude.json")
+}
+
+// CacheDir returns the directory used for on-disk caches (~/.claude/cchline/cache)
+func CacheDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".claude", "cchline", "cache")
+}
+
+// ClaudeProjectsDirs returns the directories holding Claude Code transcripts.
+// CLAUDE_CONFIG_DIR（可用逗号分隔多个）优先，否则使用 ~/.claude/projects 与 ~/.config/claude/projects
+func ClaudeProjectsDirs() []string {
+	var roots []string
+	if env := os.Getenv("CLAUDE_CONFIG_DIR"); env != "" {
+		for _, root := range strings.Split(env, ",") {
+			if root = strings.TrimSpace(root); root != "" {
+				roots = append(roots, root)
+			}
+		}
+	} else {
+		home := os.Getenv("HOME")
+		roots = []string{filepath.Join(home, ".claude"), filepath.Join(home, ".config", "claude")}
+	}
+
+	var dirs []string
+	for _, root := range roots {
+		dir := filepath.Join(root, "projects")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
 // LoadConfig loads configuration from ~/.claude/cchline/config.toml
 // Returns default configuration if file doesn't exist
 func LoadConfig() (*SimpleConfig, error) {
@@ -182,9 +448,18 @@ func LoadConfig() (*SimpleConfig, error) {
 
 	// Default configuration
 	config := &SimpleConfig{
-		Theme:        ThemeModeNerdFont,
-		Separator:    " | ",
-		SegmentOrder: DefaultSegmentOrder,
+		Theme:         ThemeModeNerdFont,
+		Separator:     " | ",
+		SegmentOrder:  DefaultSegmentOrder,
+		Git:           DefaultGitOptions(),
+		ContextWindow: DefaultContextWindowOptions(),
+		ProgressBar:   DefaultProgressBarOptions(),
+		ContextEta:    DefaultContextEtaOptions(),
+		Cost:          DefaultCostOptions(),
+		Todos:         DefaultTodosOptions(),
+		Tools:         DefaultToolsOptions(),
+		SessionTitle:  DefaultSessionTitleOptions(),
+		APIErrors:     DefaultAPIErrorsOptions(),
 	}
 	config.SegmentEnabled = DefaultSegmentEnabledForOrder(config.SegmentOrder)
 
@@ -195,16 +470,41 @@ func LoadConfig() (*SimpleConfig, error) {
 	}
 
 	type diskConfig struct {
-		Theme          ThemeMode      `toml:"theme"`
-		Separator      string         `toml:"separator"`
-		SegmentOrder   []string       `toml:"segment_order"`
-		SegmentEnabled []bool         `toml:"segment_enabled"`
-		Segments       SegmentToggles `toml:"segments"` // legacy
-		CCHApiKey      string         `toml:"cch_api_key"`
-		CCHURL         string         `toml:"cch_url"`
+		Theme          ThemeMode            `toml:"theme"`
+		Separator      string               `toml:"separator"`
+		SegmentOrder   []string             `toml:"segment_order"`
+		SegmentEnabled []bool               `toml:"segment_enabled"`
+		Segments       SegmentToggles       `toml:"segments"` // legacy
+		CCHApiKey      string               `toml:"cch_api_key"`
+		CCHURL         string               `toml:"cch_url"`
+		Git            GitOptions           `toml:"git"`
+		GitDiff        GitDiffOptions       `toml:"git_diff"`
+		ContextWindow  ContextWindowOptions `toml:"context_window"`
+		ProgressBar    ProgressBarOptions   `toml:"progress_bar"`
+		ContextEta     ContextEtaOptions    `toml:"context_eta"`
+		Cost           CostOptions          `toml:"cost"`
+		Metrics        MetricsOptions       `toml:"metrics"`
+		Todos          TodosOptions         `toml:"todos"`
+		Tools          ToolsOptions         `toml:"tools"`
+		SessionTitle   SessionTitleOptions  `toml:"session_title"`
+		Files          FilesOptions         `toml:"files"`
+		APIErrors      APIErrorsOptions     `toml:"api_errors"`
+		CCVersion      CCVersionOptions     `toml:"cc_version"`
+		Model          ModelOptions         `toml:"model"`
 	}
 
-	var disk diskConfig
+	// 预填默认值，未出现在配置文件中的选项保持默认
+	disk := diskConfig{
+		Git:           config.Git,
+		ContextWindow: config.ContextWindow,
+		ProgressBar:   config.ProgressBar,
+		ContextEta:    config.ContextEta,
+		Cost:          config.Cost,
+		Todos:         config.Todos,
+		Tools:         config.Tools,
+		SessionTitle:  config.SessionTitle,
+		APIErrors:     config.APIErrors,
+	}
 	meta, err := toml.Decode(string(data), &disk)
 	if err != nil {
 		// Config format incompatible, use default config
@@ -228,6 +528,20 @@ func LoadConfig() (*SimpleConfig, error) {
 
 	config.CCHApiKey = disk.CCHApiKey
 	config.CCHURL = disk.CCHURL
+	config.Git = disk.Git
+	config.GitDiff = disk.GitDiff
+	config.ContextWindow = disk.ContextWindow
+	config.ProgressBar = disk.ProgressBar
+	config.ContextEta = disk.ContextEta
+	config.Cost = disk.Cost
+	config.Metrics = disk.Metrics
+	config.Todos = disk.Todos
+	config.Tools = disk.Tools
+	config.SessionTitle = disk.SessionTitle
+	config.Files = disk.Files
+	config.APIErrors = disk.APIErrors
+	config.CCVersion = disk.CCVersion
+	config.Model = disk.Model
 
 	expectedEnabled := NonBreakSegmentCount(config.SegmentOrder)
 	if len(disk.SegmentEnabled) == expectedEnabled {
@@ -39,11 +39,33 @@ const (
 	SegmentOutputStyle   SegmentID = "output_style"
 	SegmentUpdate        SegmentID = "update"
 	// CCH Segments
-	SegmentCCHModel    SegmentID = "cch_model"
-	SegmentCCHProvider SegmentID = "cch_provider"
-	SegmentCCHCost     SegmentID = "cch_cost"
-	SegmentCCHRequests SegmentID = "cch_requests"
-	SegmentCCHLimits   SegmentID = "cch_limits"
+	SegmentCCHModel     SegmentID = "cch_model"
+	SegmentCCHProvider  SegmentID = "cch_provider"
+	SegmentCCHCost      SegmentID = "cch_cost"
+	SegmentCCHRequests  SegmentID = "cch_requests"
+	SegmentCCHLimits    SegmentID = "cch_limits"
+	SegmentGitDiff      SegmentID = "git_diff"
+	SegmentContextEta   SegmentID = "context_eta"
+	SegmentCache        SegmentID = "cache"
+	SegmentDailyUsage   SegmentID = "daily_usage"
+	SegmentWeeklyUsage  SegmentID = "weekly_usage"
+	SegmentMonthlyUsage SegmentID = "monthly_usage"
+	SegmentBlock        SegmentID = "block"
+	SegmentTodos        SegmentID = "todos"
+	SegmentTools        SegmentID = "tools"
+	SegmentSubagents    SegmentID = "subagents"
+	SegmentSessionTitle SegmentID = "session_title"
+	SegmentFiles        SegmentID = "files"
+	SegmentTurns        SegmentID = "turns"
+	SegmentThinking     SegmentID = "thinking"
+	SegmentAPIErrors    SegmentID = "api_errors"
+	SegmentMCP          SegmentID = "mcp"
+	SegmentCCVersion    SegmentID = "cc_version"
+	SegmentPythonEnv    SegmentID = "python_env"
+	SegmentGoVersion    SegmentID = "go_version"
+	SegmentNodeVersion  SegmentID = "node_version"
+	SegmentKubeContext  SegmentID = "kube_context"
+	SegmentCloudProfile SegmentID = "cloud_profile"
 	// Line Break
 	SegmentLineBreak SegmentID = "---"
 )
@@ -75,6 +97,7 @@ var (
 	colorYellow       = lipgloss.Color("3")  // 3
 	colorGreen        = lipgloss.Color("2")  // 2
 	colorCyan         = lipgloss.Color("6")  // 6
+	colorLightRed     = lipgloss.Color("9")  // 9
 )
 
 // Default 主题图标 (Emoji)
@@ -89,11 +112,33 @@ const (
 	DefaultIconOutputStyle = "🎯"
 	DefaultIconUpdate      = "🔄"
 	// CCH Icons
-	DefaultIconCCHModel    = "🔮"
-	DefaultIconCCHProvider = "🏢"
-	DefaultIconCCHCost     = "💵"
-	DefaultIconCCHRequests = "📈"
-	DefaultIconCCHLimits   = "🚦"
+	DefaultIconCCHModel     = "🔮"
+	DefaultIconCCHProvider  = "🏢"
+	DefaultIconCCHCost      = "💵"
+	DefaultIconCCHRequests  = "📈"
+	DefaultIconCCHLimits    = "🚦"
+	DefaultIconGitDiff      = "🔀"
+	DefaultIconContextEta   = "⏳"
+	DefaultIconCache        = "💾"
+	DefaultIconDailyUsage   = "📅"
+	DefaultIconWeeklyUsage  = "🗓"
+	DefaultIconMonthlyUsage = "📆"
+	DefaultIconBlock        = "⌛"
+	DefaultIconTodos        = "☑"
+	DefaultIconTools        = "🛠"
+	DefaultIconSubagents    = "🤖"
+	DefaultIconSessionTitle = "📝"
+	DefaultIconFiles        = "📄"
+	DefaultIconTurns        = "💬"
+	DefaultIconThinking     = "💭"
+	DefaultIconAPIErrors    = "⚠"
+	DefaultIconMCP          = "🔌"
+	DefaultIconCCVersion    = "◆"
+	DefaultIconPythonEnv    = "🐍"
+	DefaultIconGoVersion    = "🐹"
+	DefaultIconNodeVersion  = "⬢"
+	DefaultIconKubeContext  = "☸"
+	DefaultIconCloudProfile = "☁"
 )
 
 // Nerd Font 主题图标 (Unicode 码点)
@@ -108,11 +153,33 @@ const (
 	NerdFontIconOutputStyle = "\U000F12F5" // nf-md-flag_variant
 	NerdFontIconUpdate      = "\uf021"     // nf-fa-refresh
 	// CCH Icons
-	NerdFontIconCCHModel    = "\U000F02A1" // nf-md-ghost
-	NerdFontIconCCHProvider = "\U000F0F74" // nf-md-server
-	NerdFontIconCCHCost     = "\U000F01E0" // nf-md-cash
-	NerdFontIconCCHRequests = "\U000F0127" // nf-md-chart_line
-	NerdFontIconCCHLimits   = "\U000F0A1B" // nf-md-gauge
+	NerdFontIconCCHModel     = "\U000F02A1" // nf-md-ghost
+	NerdFontIconCCHProvider  = "\U000F0F74" // nf-md-server
+	NerdFontIconCCHCost      = "\U000F01E0" // nf-md-cash
+	NerdFontIconCCHRequests  = "\U000F0127" // nf-md-chart_line
+	NerdFontIconCCHLimits    = "\U000F0A1B" // nf-md-gauge
+	NerdFontIconGitDiff      = "\uf47f"     // nf-oct-git_compare
+	NerdFontIconContextEta   = "\uf252"     // nf-fa-hourglass_half
+	NerdFontIconCache        = "\uf1c0"     // nf-fa-database
+	NerdFontIconDailyUsage   = "\uf073"     // nf-fa-calendar
+	NerdFontIconWeeklyUsage  = "\uf274"     // nf-fa-calendar_check_o
+	NerdFontIconMonthlyUsage = "\uf133"     // nf-fa-calendar_o
+	NerdFontIconBlock        = "\uf253"     // nf-fa-hourglass_end
+	NerdFontIconTodos        = "\uf0ae"     // nf-fa-tasks
+	NerdFontIconTools        = "\uf0ad"     // nf-fa-wrench
+	NerdFontIconSubagents    = "\uf544"     // nf-fa-robot
+	NerdFontIconSessionTitle = "\uf249"     // nf-fa-sticky_note
+	NerdFontIconFiles        = "\uf15b"     // nf-fa-file
+	NerdFontIconTurns        = "\uf086"     // nf-fa-comments
+	NerdFontIconThinking     = "\uf0eb"     // nf-fa-lightbulb_o
+	NerdFontIconAPIErrors    = "\uf071"     // nf-fa-warning
+	NerdFontIconMCP          = "\uf1e6"     // nf-fa-plug
+	NerdFontIconCCVersion    = "\uf02b"     // nf-fa-tag
+	NerdFontIconPythonEnv    = "\ue73c"     // nf-dev-python
+	NerdFontIconGoVersion    = "\ue627"     // nf-seti-go
+	NerdFontIconNodeVersion  = "\ue718"     // nf-dev-nodejs_small
+	NerdFontIconKubeContext  = "\U000f10fe" // nf-md-kubernetes
+	NerdFontIconCloudProfile = "\uf0c2"     // nf-fa-cloud
 )
 
 // segmentColors 定义各 Segment 的颜色配置 (与图标无关)
@@ -131,11 +198,33 @@ var segmentColors = map[SegmentID]struct {
 	SegmentOutputStyle:   {&colorCyan, &colorCyan, true},
 	SegmentUpdate:        {&colorLightYellow, &colorLightYellow, false},
 	// CCH Segments
-	SegmentCCHModel:    {&colorLightMagenta, &colorLightMagenta, true},
-	SegmentCCHProvider: {&colorLightBlue, &colorLightBlue, true},
-	SegmentCCHCost:     {&colorYellow, &colorYellow, true},
-	SegmentCCHRequests: {&colorLightGreen, &colorLightGreen, false},
-	SegmentCCHLimits:   {&colorLightCyan, &colorLightCyan, false},
+	SegmentCCHModel:     {&colorLightMagenta, &colorLightMagenta, true},
+	SegmentCCHProvider:  {&colorLightBlue, &colorLightBlue, true},
+	SegmentCCHCost:      {&colorYellow, &colorYellow, true},
+	SegmentCCHRequests:  {&colorLightGreen, &colorLightGreen, false},
+	SegmentCCHLimits:    {&colorLightCyan, &colorLightCyan, false},
+	SegmentGitDiff:      {&colorLightBlue, &colorLightBlue, false},
+	SegmentContextEta:   {&colorLightMagenta, &colorLightMagenta, false},
+	SegmentCache:        {&colorCyan, &colorCyan, false},
+	SegmentDailyUsage:   {&colorYellow, &colorYellow, false},
+	SegmentWeeklyUsage:  {&colorYellow, &colorYellow, false},
+	SegmentMonthlyUsage: {&colorYellow, &colorYellow, false},
+	SegmentBlock:        {&colorLightYellow, &colorLightYellow, false},
+	SegmentTodos:        {&colorLightGreen, &colorLightGreen, false},
+	SegmentTools:        {&colorLightBlue, &colorLightBlue, false},
+	SegmentSubagents:    {&colorLightMagenta, &colorLightMagenta, false},
+	SegmentSessionTitle: {&colorLightCyan, &colorLightCyan, false},
+	SegmentFiles:        {&colorLightYellow, &colorLightYellow, false},
+	SegmentTurns:        {&colorCyan, &colorCyan, false},
+	SegmentThinking:     {&colorLightMagenta, &colorLightMagenta, false},
+	SegmentAPIErrors:    {&colorLightRed, &colorLightRed, true},
+	SegmentMCP:          {&colorLightBlue, &colorLightBlue, false},
+	SegmentCCVersion:    {&colorLightCyan, &colorLightCyan, false},
+	SegmentPythonEnv:    {&colorYellow, &colorYellow, false},
+	SegmentGoVersion:    {&colorCyan, &colorCyan, false},
+	SegmentNodeVersion:  {&colorGreen, &colorGreen, false},
+	SegmentKubeContext:  {&colorLightBlue, &colorLightBlue, false},
+	SegmentCloudProfile: {&colorLightYellow, &colorLightYellow, false},
 }
 
 // defaultIcons Default 主题图标映射
@@ -150,11 +239,33 @@ var defaultIcons = map[SegmentID]string{
 	SegmentOutputStyle:   DefaultIconOutputStyle,
 	SegmentUpdate:        DefaultIconUpdate,
 	// CCH Segments
-	SegmentCCHModel:    DefaultIconCCHModel,
-	SegmentCCHProvider: DefaultIconCCHProvider,
-	SegmentCCHCost:     DefaultIconCCHCost,
-	SegmentCCHRequests: DefaultIconCCHRequests,
-	SegmentCCHLimits:   DefaultIconCCHLimits,
+	SegmentCCHModel:     DefaultIconCCHModel,
+	SegmentCCHProvider:  DefaultIconCCHProvider,
+	SegmentCCHCost:      DefaultIconCCHCost,
+	SegmentCCHRequests:  DefaultIconCCHRequests,
+	SegmentCCHLimits:    DefaultIconCCHLimits,
+	SegmentGitDiff:      DefaultIconGitDiff,
+	SegmentContextEta:   DefaultIconContextEta,
+	SegmentCache:        DefaultIconCache,
+	SegmentDailyUsage:   DefaultIconDailyUsage,
+	SegmentWeeklyUsage:  DefaultIconWeeklyUsage,
+	SegmentMonthlyUsage: DefaultIconMonthlyUsage,
+	SegmentBlock:        DefaultIconBlock,
+	SegmentTodos:        DefaultIconTodos,
+	SegmentTools:        DefaultIconTools,
+	SegmentSubagents:    DefaultIconSubagents,
+	SegmentSessionTitle: DefaultIconSessionTitle,
+	SegmentFiles:        DefaultIconFiles,
+	SegmentTurns:        DefaultIconTurns,
+	SegmentThinking:     DefaultIconThinking,
+	SegmentAPIErrors:    DefaultIconAPIErrors,
+	SegmentMCP:          DefaultIconMCP,
+	SegmentCCVersion:    DefaultIconCCVersion,
+	SegmentPythonEnv:    DefaultIconPythonEnv,
+	SegmentGoVersion:    DefaultIconGoVersion,
+	SegmentNodeVersion:  DefaultIconNodeVersion,
+	SegmentKubeContext:  DefaultIconKubeContext,
+	SegmentCloudProfile: DefaultIconCloudProfile,
 }
 
 // nerdFontIcons Nerd Font 主题图标映射
@@ -169,11 +280,33 @@ var nerdFontIcons = map[SegmentID]string{
 	SegmentOutputStyle:   NerdFontIconOutputStyle,
 	SegmentUpdate:        NerdFontIconUpdate,
 	// CCH Segments
-	SegmentCCHModel:    NerdFontIconCCHModel,
-	SegmentCCHProvider: NerdFontIconCCHProvider,
-	SegmentCCHCost:     NerdFontIconCCHCost,
-	SegmentCCHRequests: NerdFontIconCCHRequests,
-	SegmentCCHLimits:   NerdFontIconCCHLimits,
+	SegmentCCHModel:     NerdFontIconCCHModel,
+	SegmentCCHProvider:  NerdFontIconCCHProvider,
+	SegmentCCHCost:      NerdFontIconCCHCost,
+	SegmentCCHRequests:  NerdFontIconCCHRequests,
+	SegmentCCHLimits:    NerdFontIconCCHLimits,
+	SegmentGitDiff:      NerdFontIconGitDiff,
+	SegmentContextEta:   NerdFontIconContextEta,
+	SegmentCache:        NerdFontIconCache,
+	SegmentDailyUsage:   NerdFontIconDailyUsage,
+	SegmentWeeklyUsage:  NerdFontIconWeeklyUsage,
+	SegmentMonthlyUsage: NerdFontIconMonthlyUsage,
+	SegmentBlock:        NerdFontIconBlock,
+	SegmentTodos:        NerdFontIconTodos,
+	SegmentTools:        NerdFontIconTools,
+	SegmentSubagents:    NerdFontIconSubagents,
+	SegmentSessionTitle: NerdFontIconSessionTitle,
+	SegmentFiles:        NerdFontIconFiles,
+	SegmentTurns:        NerdFontIconTurns,
+	SegmentThinking:     NerdFontIconThinking,
+	SegmentAPIErrors:    NerdFontIconAPIErrors,
+	SegmentMCP:          NerdFontIconMCP,
+	SegmentCCVersion:    NerdFontIconCCVersion,
+	SegmentPythonEnv:    NerdFontIconPythonEnv,
+	SegmentGoVersion:    NerdFontIconGoVersion,
+	SegmentNodeVersion:  NerdFontIconNodeVersion,
+	SegmentKubeContext:  NerdFontIconKubeContext,
+	SegmentCloudProfile: NerdFontIconCloudProfile,
 }
 
 // GetSegmentTheme 根据主题模式获取 Segment 主题配置
@@ -201,6 +334,18 @@ func GetSegmentTheme(id SegmentID, mode ThemeMode) SegmentTheme {
 	}
 }
 
+// PercentColor returns the threshold color for a percentage: green, yellow at warn, red at critical
+func PercentColor(percent, warn, critical float64) *lipgloss.Color {
+	switch {
+	case percent >= critical:
+		return &colorLightRed
+	case percent >= warn:
+		return &colorLightYellow
+	default:
+		return &colorLightGreen
+	}
+}
+
 // ApplyColor applies foreground color to text
 func ApplyColor(text string, c *lipgloss.Color) string {
 	if c == nil {
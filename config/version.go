@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SemVer is a parsed major.minor.patch version; pre-release and build metadata are ignored
+type SemVer struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// String formats the version as major.minor.patch
+func (v SemVer) String() string {
+	return strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+}
+
+// ParseSemVer parses versions such as "1.2.3", "v1.2.3" or "1.2.3-beta.1"
+func ParseSemVer(token string) (SemVer, bool) {
+	token = strings.TrimSpace(token)
+	token = strings.TrimPrefix(token, "v")
+	token = strings.SplitN(token, "-", 2)[0]
+	token = strings.SplitN(token, "+", 2)[0]
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return SemVer{}, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil || major < 0 {
+		return SemVer{}, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor < 0 {
+		return SemVer{}, false
+	}
+	patch, err := strconv.Atoi(parts[2])
+	if err != nil || patch < 0 {
+		return SemVer{}, false
+	}
+
+	return SemVer{Major: major, Minor: minor, Patch: patch}, true
+}
+
+// CompareSemVer returns -1, 0 or 1 when a is lower than, equal to or higher than b
+func CompareSemVer(a, b SemVer) int {
+	if a.Major != b.Major {
+		if a.Major < b.Major {
+			return -1
+		}
+		return 1
+	}
+	if a.Minor != b.Minor {
+		if a.Minor < b.Minor {
+			return -1
+		}
+		return 1
+	}
+	if a.Patch != b.Patch {
+		if a.Patch < b.Patch {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
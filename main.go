@@ -3,13 +3,16 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 
 	"github.com/WAY29/cchline/cch"
 	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/metrics"
 	"github.com/WAY29/cchline/render"
+	"github.com/WAY29/cchline/report"
 	"github.com/WAY29/cchline/segment"
 	"github.com/WAY29/cchline/tui"
 )
@@ -26,6 +29,18 @@ var (
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "report":
+			runReport(os.Args[2:])
+			return
+		case "metrics":
+			runMetrics(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Parse()
 
 	// Show version
@@ -61,41 +76,118 @@ func main() {
 		fmt.Println("  cchline -c               Open configuration")
 		fmt.Println("  cchline -v               Show version")
 		fmt.Println("  cchline -k KEY -u URL    Use CCH API")
+		fmt.Println("  cchline report [daily|weekly|monthly|session|model] [--since DATE] [--until DATE] [--project DIR] [--breakdown] [--json|--csv]")
+		fmt.Println("                           Show usage and cost from local transcripts")
+		fmt.Println("  cchline metrics [-o FILE|-] < input.json")
+		fmt.Println("                           Write Prometheus textfile metrics")
 		return
 	}
 
 	// Initialize CCH client if configured
-	var cchClient *cch.Client
-	apiKey := *cchApiKey
-	url := *cchURL
-	// Fallback to config file values
+	cchClient := newCCHClient(cfg, *cchApiKey, *cchURL)
+
+	// Read stdin JSON
+	input := readInput()
+
+	// Collect all segment data
+	segments := collectAllSegments(cfg, &input, cchClient)
+
+	// Generate status line
+	generator := render.NewStatusLineGenerator(cfg)
+	output := generator.Generate(segments)
+
+	// Output to stdout
+	fmt.Print(output)
+
+	// Export metrics for node_exporter's textfile collector
+	if cfg.Metrics.Enabled {
+		snap := metrics.Collect(cfg, &input, cchClient, segments)
+		if err := metrics.WriteFile(metricsPath(cfg, &input), snap); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		}
+	}
+}
+
+// newCCHClient creates a CCH client from flags, falling back to config file values
+func newCCHClient(cfg *config.SimpleConfig, apiKey, url string) *cch.Client {
 	if apiKey == "" {
 		apiKey = cfg.CCHApiKey
 	}
 	if url == "" {
 		url = cfg.CCHURL
 	}
-	if apiKey != "" && url != "" {
-		cchClient = cch.NewClient(url, apiKey)
+	if apiKey == "" || url == "" {
+		return nil
 	}
+	return cch.NewClient(url, apiKey)
+}
 
-	// Read stdin JSON
+// readInput decodes the status line JSON from stdin
+func readInput() config.InputData {
 	reader := bufio.NewReader(os.Stdin)
 	var input config.InputData
 	if err := json.NewDecoder(reader).Decode(&input); err != nil {
 		fmt.Fprintf(os.Stderr, "Error parsing input: %v\n", err)
 		os.Exit(1)
 	}
+	return input
+}
 
-	// Collect all segment data
-	segments := collectAllSegments(cfg, &input, cchClient)
+// metricsPath returns the per-project metrics file derived from the configured path
+func metricsPath(cfg *config.SimpleConfig, input *config.InputData) string {
+	path := cfg.Metrics.Path
+	if path == "" {
+		path = metrics.DefaultPath()
+	}
+	return metrics.ProjectPath(path, input)
+}
 
-	// Generate status line
-	generator := render.NewStatusLineGenerator(cfg)
-	output := generator.Generate(segments)
+func runReport(args []string) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := report.Run(args, cfg, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+}
 
-	// Output to stdout
-	fmt.Print(output)
+// runMetrics writes metrics for the status line JSON read from stdin
+func runMetrics(args []string) {
+	fs := flag.NewFlagSet("metrics", flag.ExitOnError)
+	output := fs.String("o", "", "Metrics file to write, \"-\" for stdout (default: per-project file derived from [metrics] path)")
+	apiKey := fs.String("k", "", "CCH API key(Optional)")
+	url := fs.String("u", "", "CCH server URL(Optional)")
+	fs.Parse(args)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	input := readInput()
+	cchClient := newCCHClient(cfg, *apiKey, *url)
+	snap := metrics.Collect(cfg, &input, cchClient, nil)
+
+	if *output == "-" {
+		err = metrics.Write(os.Stdout, snap)
+	} else {
+		path := *output
+		if path == "" {
+			path = metricsPath(cfg, &input)
+		}
+		err = metrics.WriteFile(path, snap)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		os.Exit(1)
+	}
 }
 
 func collectAllSegments(cfg *config.SimpleConfig, input *config.InputData, cchClient *cch.Client) []segment.SegmentResult {
@@ -108,7 +200,7 @@ func collectAllSegments(cfg *config.SimpleConfig, input *config.InputData, cchCl
 	}{
 		"model": {
 			id:      config.SegmentModel,
-			collect: (&segment.ModelSegment{}).Collect,
+			collect: (&segment.ModelSegment{Options: cfg.Model}).Collect,
 		},
 		"directory": {
 			id:      config.SegmentDirectory,
@@ -116,11 +208,11 @@ func collectAllSegments(cfg *config.SimpleConfig, input *config.InputData, cchCl
 		},
 		"git": {
 			id:      config.SegmentGit,
-			collect: (&segment.GitSegment{}).Collect,
+			collect: (&segment.GitSegment{Options: cfg.Git}).Collect,
 		},
 		"context_window": {
 			id:      config.SegmentContextWindow,
-			collect: (&segment.ContextWindowSegment{}).Collect,
+			collect: (&segment.ContextWindowSegment{Options: cfg.ContextWindow}).Collect,
 		},
 		"usage": {
 			id:      config.SegmentUsage,
@@ -128,7 +220,7 @@ func collectAllSegments(cfg *config.SimpleConfig, input *config.InputData, cchCl
 		},
 		"cost": {
 			id:      config.SegmentCost,
-			collect: (&segment.CostSegment{}).Collect,
+			collect: (&segment.CostSegment{Options: cfg.Cost}).Collect,
 		},
 		"session": {
 			id:      config.SegmentSession,
@@ -163,6 +255,94 @@ func collectAllSegments(cfg *config.SimpleConfig, input *config.InputData, cchCl
 			id:      config.SegmentCCHLimits,
 			collect: (&segment.CCHLimitsSegment{Client: cchClient}).Collect,
 		},
+		"git_diff": {
+			id:      config.SegmentGitDiff,
+			collect: (&segment.GitDiffSegment{Options: cfg.GitDiff}).Collect,
+		},
+		"context_eta": {
+			id:      config.SegmentContextEta,
+			collect: (&segment.ContextEtaSegment{Options: cfg.ContextEta, ContextWindow: cfg.ContextWindow}).Collect,
+		},
+		"cache": {
+			id:      config.SegmentCache,
+			collect: (&segment.CacheSegment{}).Collect,
+		},
+		"daily_usage": {
+			id:      config.SegmentDailyUsage,
+			collect: (&segment.DailyUsageSegment{Pricing: cfg.Cost.Pricing}).Collect,
+		},
+		"weekly_usage": {
+			id:      config.SegmentWeeklyUsage,
+			collect: (&segment.WeeklyUsageSegment{Pricing: cfg.Cost.Pricing}).Collect,
+		},
+		"monthly_usage": {
+			id:      config.SegmentMonthlyUsage,
+			collect: (&segment.MonthlyUsageSegment{Pricing: cfg.Cost.Pricing}).Collect,
+		},
+		"block": {
+			id:      config.SegmentBlock,
+			collect: (&segment.BlockSegment{Pricing: cfg.Cost.Pricing}).Collect,
+		},
+		"todos": {
+			id:      config.SegmentTodos,
+			collect: (&segment.TodosSegment{Options: cfg.Todos}).Collect,
+		},
+		"tools": {
+			id:      config.SegmentTools,
+			collect: (&segment.ToolsSegment{Options: cfg.Tools}).Collect,
+		},
+		"subagents": {
+			id:      config.SegmentSubagents,
+			collect: (&segment.SubagentsSegment{Pricing: cfg.Cost.Pricing}).Collect,
+		},
+		"session_title": {
+			id:      config.SegmentSessionTitle,
+			collect: (&segment.SessionTitleSegment{Options: cfg.SessionTitle}).Collect,
+		},
+		"files": {
+			id:      config.SegmentFiles,
+			collect: (&segment.FilesSegment{Options: cfg.Files}).Collect,
+		},
+		"turns": {
+			id:      config.SegmentTurns,
+			collect: (&segment.TurnsSegment{}).Collect,
+		},
+		"thinking": {
+			id:      config.SegmentThinking,
+			collect: (&segment.ThinkingSegment{}).Collect,
+		},
+		"api_errors": {
+			id:      config.SegmentAPIErrors,
+			collect: (&segment.APIErrorsSegment{Options: cfg.APIErrors}).Collect,
+		},
+		"mcp": {
+			id:      config.SegmentMCP,
+			collect: (&segment.MCPSegment{}).Collect,
+		},
+		"cc_version": {
+			id:      config.SegmentCCVersion,
+			collect: (&segment.CCVersionSegment{Options: cfg.CCVersion}).Collect,
+		},
+		"python_env": {
+			id:      config.SegmentPythonEnv,
+			collect: (&segment.PythonEnvSegment{}).Collect,
+		},
+		"go_version": {
+			id:      config.SegmentGoVersion,
+			collect: (&segment.GoVersionSegment{}).Collect,
+		},
+		"node_version": {
+			id:      config.SegmentNodeVersion,
+			collect: (&segment.NodeVersionSegment{}).Collect,
+		},
+		"kube_context": {
+			id:      config.SegmentKubeContext,
+			collect: (&segment.KubeContextSegment{}).Collect,
+		},
+		"cloud_profile": {
+			id:      config.SegmentCloudProfile,
+			collect: (&segment.CloudProfileSegment{}).Collect,
+		},
 	}
 
 	var results []segment.SegmentResult
@@ -0,0 +1,195 @@
+// Package metrics exports status line values as a Prometheus/OpenMetrics
+// textfile, suitable for node_exporter's textfile collector.
+package metrics
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WAY29/cchline/cch"
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// Metric is a single gauge sample
+type Metric struct {
+	Name  string
+	Help  string
+	Value float64
+}
+
+// Snapshot holds the metrics of one render together with their labels
+type Snapshot struct {
+	Project string
+	Model   string
+	Time    time.Time
+	Metrics []Metric
+}
+
+// DefaultPath returns the metrics file used when no path is configured
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".claude", "cchline", "metrics", "cchline.prom")
+}
+
+// ProjectPath derives the metrics file of the input's project from path,
+// e.g. cchline.prom becomes cchline-<hash>.prom, so concurrent sessions in
+// different projects do not overwrite each other
+func ProjectPath(path string, input *config.InputData) string {
+	dir := input.Workspace.ProjectDir
+	if dir == "" {
+		dir = input.Workspace.CurrentDir
+	}
+	h := fnv.New64a()
+	h.Write([]byte(dir))
+
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%016x%s", strings.TrimSuffix(path, ext), h.Sum64(), ext)
+}
+
+// Collect builds a snapshot from the segments enabled in cfg, reusing already
+// collected segment results and collecting the missing ones on demand.
+// Metrics of disabled segments are omitted.
+func Collect(cfg *config.SimpleConfig, input *config.InputData, client *cch.Client, results []segment.SegmentResult) Snapshot {
+	collected := make(map[config.SegmentID]segment.SegmentData, len(results))
+	for _, r := range results {
+		collected[r.ID] = r.Data
+	}
+	enabled := func(id config.SegmentID) bool {
+		return config.IsSegmentEnabled(cfg.SegmentOrder, cfg.SegmentEnabled, string(id))
+	}
+	data := func(id config.SegmentID, seg segment.Segment) (segment.SegmentData, bool) {
+		if !enabled(id) {
+			return segment.SegmentData{}, false
+		}
+		if d, ok := collected[id]; ok {
+			return d, true
+		}
+		return seg.Collect(input), true
+	}
+
+	snap := Snapshot{
+		Project: projectName(input),
+		Model:   input.Model.ID,
+		Time:    time.Now(),
+	}
+	if snap.Model == "" {
+		snap.Model = input.Model.DisplayName
+	}
+
+	context, _ := data(config.SegmentContextWindow, &segment.ContextWindowSegment{Options: cfg.ContextWindow})
+	if tokens, err := strconv.Atoi(context.Metadata["tokens"]); err == nil {
+		snap.add("cchline_context_tokens", "Tokens in the current context window.", float64(tokens))
+	}
+	if limit, err := strconv.Atoi(context.Metadata["limit"]); err == nil {
+		snap.add("cchline_context_limit_tokens", "Context window size of the current model.", float64(limit))
+	}
+
+	if cost, ok := data(config.SegmentCost, &segment.CostSegment{Options: cfg.Cost}); ok {
+		sessionCost, _ := strconv.ParseFloat(cost.Metadata["cost"], 64)
+		snap.add("cchline_session_cost_usd", "Cost of the current session in USD.", sessionCost)
+	}
+
+	// CCH 统计由各段共用同一份缓存，只在对应的段开启时请求
+	cchCost, cchRequests := enabled(config.SegmentCCHCost), enabled(config.SegmentCCHRequests)
+	if client != nil && (cchCost || cchRequests) {
+		if stats, err := client.GetStats(); err == nil {
+			if cchCost {
+				snap.add("cchline_cch_today_cost_usd", "CCH cost today in USD.", stats.TodayCost)
+				snap.add("cchline_cch_daily_quota_usd", "CCH daily quota in USD, 0 when unlimited.", stats.DailyQuota)
+			}
+			if cchRequests {
+				snap.add("cchline_cch_today_requests", "CCH requests today.", float64(stats.TodayRequests))
+			}
+		}
+	}
+
+	git, _ := data(config.SegmentGit, &segment.GitSegment{Options: cfg.Git})
+	if dirty, err := strconv.ParseBool(git.Metadata["dirty"]); err == nil {
+		snap.add("cchline_git_dirty", "Whether the working tree has uncommitted changes (1) or not (0).", boolValue(dirty))
+	}
+
+	snap.add("cchline_last_render_timestamp_seconds", "Unix time of the last status line render.", float64(snap.Time.Unix()))
+	return snap
+}
+
+func (s *Snapshot) add(name, help string, value float64) {
+	s.Metrics = append(s.Metrics, Metric{Name: name, Help: help, Value: value})
+}
+
+// Write writes the snapshot in OpenMetrics text format
+func Write(w io.Writer, snap Snapshot) error {
+	labels := fmt.Sprintf(`{project="%s",model="%s"}`, escapeLabel(snap.Project), escapeLabel(snap.Model))
+
+	var b strings.Builder
+	for _, m := range snap.Metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.Name, m.Help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", m.Name)
+		fmt.Fprintf(&b, "%s%s %s\n", m.Name, labels, strconv.FormatFloat(m.Value, 'f', -1, 64))
+	}
+	b.WriteString("# EOF\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteFile atomically replaces path with the snapshot so the collector never reads a partial file
+func WriteFile(path string, snap Snapshot) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := Write(tmp, snap); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	// node_exporter 通常以其他用户运行，CreateTemp 默认的 0600 读不到
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// projectName 使用项目目录名作为 project 标签
+func projectName(input *config.InputData) string {
+	dir := input.Workspace.ProjectDir
+	if dir == "" {
+		dir = input.Workspace.CurrentDir
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Base(dir)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
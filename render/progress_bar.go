@@ -0,0 +1,56 @@
+package render
+
+import (
+	"math"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// eighthBlocks 1/8 到 7/8 的左对齐方块，用于亚格精度
+var eighthBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// ProgressBar renders percent (0-100) as a bar using the configured style and glyphs
+func ProgressBar(percent float64, opts config.ProgressBarOptions) string {
+	width := opts.Width
+	if width <= 0 {
+		width = config.DefaultProgressBarOptions().Width
+	}
+	if math.IsNaN(percent) || percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	glyph := func(custom, fallback string) string {
+		if custom != "" {
+			return custom
+		}
+		return fallback
+	}
+
+	switch opts.Style {
+	case config.ProgressBarStyleASCII:
+		filled := int(math.Round(percent / 100 * float64(width)))
+		return "[" + strings.Repeat(glyph(opts.Filled, "#"), filled) + strings.Repeat(glyph(opts.Empty, "-"), width-filled) + "]"
+
+	case config.ProgressBarStyleEighths:
+		eighths := int(math.Round(percent / 100 * float64(width*8)))
+		full, partial := eighths/8, eighths%8
+
+		var b strings.Builder
+		b.WriteString(strings.Repeat(glyph(opts.Filled, "█"), full))
+		used := full
+		if partial > 0 {
+			b.WriteString(eighthBlocks[partial])
+			used++
+		}
+		b.WriteString(strings.Repeat(glyph(opts.Empty, "░"), width-used))
+		return b.String()
+
+	default:
+		filled := int(math.Round(percent / 100 * float64(width)))
+		return strings.Repeat(glyph(opts.Filled, "▰"), filled) + strings.Repeat(glyph(opts.Empty, "▱"), width-filled)
+	}
+}
@@ -2,6 +2,7 @@ package render
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/WAY29/cchline/config"
@@ -62,6 +63,11 @@ func (g *StatusLineGenerator) renderSegment(seg segment.SegmentResult) string {
 	icon := config.ApplyColor(theme.Icon, theme.IconColor)
 	text := config.ApplyColor(seg.Data.Primary, theme.TextColor)
 
+	// Prepend a progress bar for segments exposing a percentage
+	if bar := g.renderProgressBar(seg); bar != "" {
+		text = bar + " " + text
+	}
+
 	// Apply background color if present
 	if theme.BgColor != nil {
 		return config.ApplyBackground(fmt.Sprintf(" %s %s ", icon, text), theme.BgColor)
@@ -69,3 +75,28 @@ func (g *StatusLineGenerator) renderSegment(seg segment.SegmentResult) string {
 
 	return fmt.Sprintf("%s %s", icon, text)
 }
+
+// renderProgressBar renders the threshold-colored bar for seg, or "" when not enabled
+func (g *StatusLineGenerator) renderProgressBar(seg segment.SegmentResult) string {
+	opts := g.config.ProgressBar
+	if !opts.Enabled(seg.ID) {
+		return ""
+	}
+
+	value, ok := seg.Data.Metadata["percent"]
+	if !ok {
+		return ""
+	}
+	percent, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return ""
+	}
+
+	// 命中率、完成度等越高越好的百分比按剩余部分着色，阈值始终表示"越高越差"
+	severity := percent
+	if seg.Data.Metadata["percent_scale"] == "higher_is_better" {
+		severity = 100 - percent
+	}
+	color := config.PercentColor(severity, opts.WarnThreshold, opts.CriticalThreshold)
+	return config.ApplyColor(ProgressBar(percent, opts), color)
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// totals 汇总所有行
+func totals(rows []Row) Usage {
+	var total Usage
+	for _, row := range rows {
+		total.Input += row.Input
+		total.Output += row.Output
+		total.CacheWrite += row.CacheWrite
+		total.CacheRead += row.CacheRead
+		total.TotalTokens += row.TotalTokens
+		total.Cost += row.Cost
+		total.MessageCount += row.MessageCount
+	}
+	return total
+}
+
+func keyHeader(mode string) string {
+	switch mode {
+	case ModeWeekly:
+		return "Week"
+	case ModeMonthly:
+		return "Month"
+	case ModeSession:
+		return "Session"
+	case ModeModel:
+		return "Model"
+	default:
+		return "Date"
+	}
+}
+
+func writeJSON(w io.Writer, rows []Row) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Rows   []Row `json:"rows"`
+		Totals Usage `json:"totals"`
+	}{rows, totals(rows)})
+}
+
+func writeCSV(w io.Writer, rows []Row, opts Options) error {
+	cw := csv.NewWriter(w)
+	header := []string{strings.ToLower(keyHeader(opts.Mode))}
+	if opts.Mode == ModeSession {
+		header = append(header, "project")
+	}
+	if opts.Mode != ModeModel {
+		header = append(header, "models")
+	}
+	header = append(header, "input_tokens", "output_tokens", "cache_creation_tokens", "cache_read_tokens", "total_tokens", "cost_usd")
+	cw.Write(header)
+
+	record := func(row Row, models string, usage Usage) []string {
+		fields := []string{row.Key}
+		if opts.Mode == ModeSession {
+			fields = append(fields, row.Project)
+		}
+		if opts.Mode != ModeModel {
+			fields = append(fields, models)
+		}
+		return append(fields,
+			strconv.Itoa(usage.Input), strconv.Itoa(usage.Output),
+			strconv.Itoa(usage.CacheWrite), strconv.Itoa(usage.CacheRead),
+			strconv.Itoa(usage.TotalTokens), strconv.FormatFloat(usage.Cost, 'f', 4, 64))
+	}
+
+	for _, row := range rows {
+		// 明细模式下每个模型单独一行，方便按模型透视
+		if opts.Breakdown && opts.Mode != ModeModel {
+			for _, mu := range row.Models {
+				cw.Write(record(row, mu.Model, mu.Usage))
+			}
+			continue
+		}
+		cw.Write(record(row, strings.Join(row.modelNames(), ";"), row.Usage))
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTable(w io.Writer, rows []Row, opts Options) error {
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "No usage found.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	columns := []string{keyHeader(opts.Mode)}
+	if opts.Mode == ModeSession {
+		columns = append(columns, "Project")
+	}
+	if opts.Mode != ModeModel {
+		columns = append(columns, "Models")
+	}
+	columns = append(columns, "Input", "Output", "Cache Write", "Cache Read", "Total Tokens", "Cost")
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+
+	line := func(key, project, models string, usage Usage) {
+		fields := []string{key}
+		if opts.Mode == ModeSession {
+			fields = append(fields, project)
+		}
+		if opts.Mode != ModeModel {
+			fields = append(fields, models)
+		}
+		fields = append(fields,
+			formatInt(usage.Input), formatInt(usage.Output),
+			formatInt(usage.CacheWrite), formatInt(usage.CacheRead),
+			formatInt(usage.TotalTokens), fmt.Sprintf("$%.2f", usage.Cost))
+		fmt.Fprintln(tw, strings.Join(fields, "\t"))
+	}
+
+	for _, row := range rows {
+		line(row.Key, row.Project, strings.Join(row.modelNames(), ", "), row.Usage)
+		if opts.Breakdown && opts.Mode != ModeModel {
+			for _, mu := range row.Models {
+				line("└", "", mu.Model, mu.Usage)
+			}
+		}
+	}
+	line("Total", "", "", totals(rows))
+
+	return tw.Flush()
+}
+
+// formatInt 添加千位分隔符
+func formatInt(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatInt(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,279 @@
+// Package report implements the `cchline report` command, aggregating local
+// transcript usage into daily, weekly, monthly, session and model breakdowns.
+package report
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// Report modes
+const (
+	ModeDaily   = "daily"
+	ModeWeekly  = "weekly"
+	ModeMonthly = "monthly"
+	ModeSession = "session"
+	ModeModel   = "model"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Options controls which entries are reported and how they are grouped
+type Options struct {
+	Mode      string
+	Since     time.Time // 包含，零值表示不限
+	Until     time.Time // 包含当天，零值表示不限
+	Project   string    // 按工作目录子串过滤
+	Breakdown bool      // 表格与 CSV 中逐行列出各模型明细
+	Format    string
+	Pricing   map[string]config.ModelPricing
+}
+
+// Usage is the token and cost total of a group of entries
+type Usage struct {
+	Input        int     `json:"input_tokens"`
+	Output       int     `json:"output_tokens"`
+	CacheWrite   int     `json:"cache_creation_tokens"`
+	CacheRead    int     `json:"cache_read_tokens"`
+	TotalTokens  int     `json:"total_tokens"`
+	Cost         float64 `json:"cost_usd"`
+	MessageCount int     `json:"messages"`
+}
+
+func (u *Usage) add(entry segment.UsageEntry, pricing map[string]config.ModelPricing) {
+	u.Input += entry.Input
+	u.Output += entry.Output
+	u.CacheWrite += entry.CacheWrite5m + entry.CacheWrite1h
+	u.CacheRead += entry.CacheRead
+	u.TotalTokens += entry.Tokens()
+	u.Cost += entry.Cost(pricing)
+	u.MessageCount++
+}
+
+// ModelUsage is the usage of a single model within a row
+type ModelUsage struct {
+	Model string `json:"model"`
+	Usage
+}
+
+// Row is one line of the report
+type Row struct {
+	Key          string       `json:"key"`               // 日期、周起始日、月份、session ID 或模型
+	Project      string       `json:"project,omitempty"` // 仅 session 报表
+	LastActivity time.Time    `json:"last_activity"`
+	Models       []ModelUsage `json:"models"`
+	Usage
+}
+
+// modelNames 返回该行使用过的模型名
+func (r *Row) modelNames() []string {
+	names := make([]string, 0, len(r.Models))
+	for _, m := range r.Models {
+		names = append(names, m.Model)
+	}
+	return names
+}
+
+// Build groups entries into report rows according to opts
+func Build(entries []segment.UsageEntry, opts Options) []Row {
+	rows := make(map[string]*Row)
+	models := make(map[string]map[string]*ModelUsage)
+
+	for _, entry := range entries {
+		if !opts.Since.IsZero() && entry.Time.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && !entry.Time.Before(opts.Until.AddDate(0, 0, 1)) {
+			continue
+		}
+		if opts.Project != "" && !strings.Contains(entry.Project, opts.Project) {
+			continue
+		}
+
+		key := groupKey(entry, opts.Mode)
+		row := rows[key]
+		if row == nil {
+			row = &Row{Key: key}
+			rows[key] = row
+			models[key] = make(map[string]*ModelUsage)
+		}
+		if opts.Mode == ModeSession && entry.Project != "" {
+			row.Project = entry.Project
+		}
+		if entry.Time.After(row.LastActivity) {
+			row.LastActivity = entry.Time
+		}
+		row.add(entry, opts.Pricing)
+
+		model := entry.Model
+		if model == "" {
+			model = "unknown"
+		}
+		mu := models[key][model]
+		if mu == nil {
+			mu = &ModelUsage{Model: model}
+			models[key][model] = mu
+		}
+		mu.add(entry, opts.Pricing)
+	}
+
+	result := make([]Row, 0, len(rows))
+	for key, row := range rows {
+		for _, mu := range models[key] {
+			row.Models = append(row.Models, *mu)
+		}
+		sort.Slice(row.Models, func(i, j int) bool {
+			if row.Models[i].Cost != row.Models[j].Cost {
+				return row.Models[i].Cost > row.Models[j].Cost
+			}
+			return row.Models[i].Model < row.Models[j].Model
+		})
+		result = append(result, *row)
+	}
+
+	// 时间类报表按时间升序，session 按最后活动时间，model 按费用降序
+	sort.Slice(result, func(i, j int) bool {
+		switch opts.Mode {
+		case ModeSession:
+			return result[i].LastActivity.Before(result[j].LastActivity)
+		case ModeModel:
+			if result[i].Cost != result[j].Cost {
+				return result[i].Cost > result[j].Cost
+			}
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// groupKey 返回 entry 在指定报表模式下的分组 key
+func groupKey(entry segment.UsageEntry, mode string) string {
+	switch mode {
+	case ModeWeekly:
+		return segment.PeriodStart(segment.PeriodWeek, entry.Time).Format("2006-01-02")
+	case ModeMonthly:
+		return entry.Time.Local().Format("2006-01")
+	case ModeSession:
+		if entry.SessionID == "" {
+			return "unknown"
+		}
+		return entry.SessionID
+	case ModeModel:
+		if entry.Model == "" {
+			return "unknown"
+		}
+		return entry.Model
+	default:
+		return entry.Time.Local().Format("2006-01-02")
+	}
+}
+
+// Run parses the report command line and writes the report to w
+func Run(args []string, cfg *config.SimpleConfig, w io.Writer) error {
+	opts, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+	opts.Pricing = cfg.Cost.Pricing
+
+	rows := Build(segment.LoadUsageEntries(opts.Since), opts)
+	switch opts.Format {
+	case FormatJSON:
+		return writeJSON(w, rows)
+	case FormatCSV:
+		return writeCSV(w, rows, opts)
+	default:
+		return writeTable(w, rows, opts)
+	}
+}
+
+func parseArgs(args []string) (Options, error) {
+	opts := Options{Mode: ModeDaily, Format: FormatTable}
+
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	since := fs.String("since", "", "Only include usage on or after this date (YYYY-MM-DD or YYYYMMDD)")
+	until := fs.String("until", "", "Only include usage on or before this date (YYYY-MM-DD or YYYYMMDD)")
+	fs.StringVar(&opts.Project, "project", "", "Only include sessions whose working directory contains this string")
+	fs.BoolVar(&opts.Breakdown, "breakdown", false, "Show per-model rows in table and CSV output")
+	asJSON := fs.Bool("json", false, "Output JSON")
+	asCSV := fs.Bool("csv", false, "Output CSV")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cchline report [daily|weekly|monthly|session|model] [flags]")
+		fs.PrintDefaults()
+	}
+	// 解析错误由调用方统一输出，避免 flag 包再打印一次
+	fs.SetOutput(io.Discard)
+	parse := func(args []string) error {
+		err := fs.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			fs.SetOutput(os.Stderr)
+			fs.Usage()
+		}
+		return err
+	}
+	if err := parse(args); err != nil {
+		return opts, err
+	}
+	// flag 包在第一个位置参数处停止解析，取出模式后继续解析其后的 flag，
+	// 因此模式可以写在任意位置：cchline report daily --json、cchline report --since 20250601 session --json
+	if fs.NArg() > 0 {
+		opts.Mode = fs.Arg(0)
+		if err := parse(fs.Args()[1:]); err != nil {
+			return opts, err
+		}
+		if fs.NArg() > 0 {
+			return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+		}
+	}
+
+	switch opts.Mode {
+	case ModeDaily, ModeWeekly, ModeMonthly, ModeSession, ModeModel:
+	default:
+		return opts, fmt.Errorf("unknown report %q, expected daily, weekly, monthly, session or model", opts.Mode)
+	}
+
+	if *asJSON && *asCSV {
+		return opts, errors.New("--json and --csv are mutually exclusive")
+	}
+	if *asJSON {
+		opts.Format = FormatJSON
+	} else if *asCSV {
+		opts.Format = FormatCSV
+	}
+
+	var err error
+	if opts.Since, err = parseDate(*since); err != nil {
+		return opts, fmt.Errorf("invalid --since: %w", err)
+	}
+	if opts.Until, err = parseDate(*until); err != nil {
+		return opts, fmt.Errorf("invalid --until: %w", err)
+	}
+	return opts, nil
+}
+
+// parseDate 解析本地时区的日期，空字符串返回零值
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{"2006-01-02", "20060102"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD date", value)
+}
@@ -0,0 +1,277 @@
+package segment
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// usageShardPrefix 用量索引按 transcript 文件分片，每个文件对应一个 usage-<key>.json 缓存
+const usageShardPrefix = "usage-"
+
+// usageIndexVersion 索引格式变化时递增，旧分片会被丢弃重建
+const usageIndexVersion = 3
+
+// UsageEntry is the usage of a single assistant message found under the Claude projects directories
+type UsageEntry struct {
+	Key          string    `json:"k,omitempty"` // message.id:requestId，用于跨文件去重
+	Time         time.Time `json:"t"`
+	SessionID    string    `json:"s,omitempty"`
+	Project      string    `json:"p,omitempty"` // 会话的工作目录
+	Model        string    `json:"m,omitempty"`
+	Input        int       `json:"i,omitempty"`
+	Output       int       `json:"o,omitempty"`
+	CacheWrite5m int       `json:"w5,omitempty"`
+	CacheWrite1h int       `json:"w1,omitempty"`
+	CacheRead    int       `json:"r,omitempty"`
+}
+
+// Tokens returns the total tokens of the entry
+func (e UsageEntry) Tokens() int {
+	return e.Input + e.Output + e.CacheWrite5m + e.CacheWrite1h + e.CacheRead
+}
+
+// Cost returns the locally priced cost of the entry, or 0 for unknown models
+func (e UsageEntry) Cost(overrides map[string]config.ModelPricing) float64 {
+	price, ok := lookupModelPricing(e.Model, overrides)
+	if !ok {
+		return 0
+	}
+	return usageCost(&UsageData{
+		InputTokens:              e.Input,
+		OutputTokens:             e.Output,
+		CacheCreationInputTokens: e.CacheWrite5m + e.CacheWrite1h,
+		CacheReadInputTokens:     e.CacheRead,
+		CacheCreation: &CacheCreationData{
+			Ephemeral5mInputTokens: e.CacheWrite5m,
+			Ephemeral1hInputTokens: e.CacheWrite1h,
+		},
+	}, price)
+}
+
+// usageFile 单个 transcript 文件的索引分片，记录已解析的字节偏移与条目
+type usageFile struct {
+	Version int          `json:"version"`
+	Size    int64        `json:"size"`
+	Offset  int64        `json:"offset"` // 已解析的完整行的字节数
+	Entries []UsageEntry `json:"entries"`
+}
+
+// transcriptFile 磁盘上 transcript 文件的大小与修改时间
+type transcriptFile struct {
+	size    int64
+	modTime time.Time
+}
+
+// 同一进程内多个统计 segment 共享已加载的分片
+var usageMemo struct {
+	sync.Mutex
+	files map[string]*usageFile
+}
+
+// LoadUsageEntries returns the deduplicated usage entries of all transcripts at or after since, sorted by time.
+// A zero since loads the full history; otherwise transcripts not modified since then are skipped without reading.
+func LoadUsageEntries(since time.Time) []UsageEntry {
+	files := listTranscriptFiles(config.ClaudeProjectsDirs())
+
+	usageMemo.Lock()
+	defer usageMemo.Unlock()
+	if usageMemo.files == nil {
+		usageMemo.files = make(map[string]*usageFile)
+	}
+
+	// 同一条消息会因会话恢复等原因出现在多个文件中，按文件路径顺序保留第一次出现
+	// 恢复时复制的消息保留原时间戳，早于 since 的文件被跳过不影响去重结果
+	entries := []UsageEntry{}
+	seen := make(map[string]bool)
+	changed := false
+	for _, path := range sortedKeys(files) {
+		file := files[path]
+		// 条目在写入时追加，文件最后修改早于 since 时不可能包含需要的条目
+		if !since.IsZero() && file.modTime.Before(since) {
+			continue
+		}
+
+		state, loaded := usageMemo.files[path]
+		if !loaded {
+			state = &usageFile{}
+			if !readCache(usageShardName(path), state) || state.Version != usageIndexVersion {
+				state = &usageFile{Version: usageIndexVersion}
+			}
+			usageMemo.files[path] = state
+		}
+		if state.Size != file.size {
+			// 文件变小说明被重写，从头解析
+			if file.size < state.Offset {
+				*state = usageFile{Version: usageIndexVersion}
+			}
+			if err := state.update(path); err == nil {
+				state.Size = file.size
+			}
+			writeCache(usageShardName(path), state)
+			changed = true
+		}
+
+		for _, entry := range state.Entries {
+			if entry.Time.Before(since) {
+				continue
+			}
+			if entry.Key != "" {
+				if seen[entry.Key] {
+					continue
+				}
+				seen[entry.Key] = true
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if changed {
+		pruneUsageShards(files)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+// usageShardName 返回 transcript 文件对应的分片缓存文件名
+func usageShardName(path string) string {
+	return usageShardPrefix + cacheKey(path) + ".json"
+}
+
+// pruneUsageShards 删除已不存在的 transcript 文件的分片，以及旧版本的单文件索引
+func pruneUsageShards(files map[string]transcriptFile) {
+	keep := make(map[string]bool, len(files))
+	for path := range files {
+		keep[usageShardName(path)] = true
+	}
+
+	dir := config.CacheDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, usageShardPrefix) && strings.HasSuffix(name, ".json") && !keep[name] {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
+
+// update 从上次的偏移继续解析新追加的完整行，末尾未写完的行留到下次
+func (f *usageFile) update(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(f.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	// 同一响应的多条记录以最后一条为准
+	keys := make(map[string]int)
+	for i, entry := range f.Entries {
+		if entry.Key != "" {
+			keys[entry.Key] = i
+		}
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		f.Offset += int64(len(line))
+
+		entry, ok := parseUsageEntry(string(line))
+		if !ok {
+			continue
+		}
+		if i, ok := keys[entry.Key]; ok && entry.Key != "" {
+			f.Entries[i] = entry
+			continue
+		}
+		if entry.Key != "" {
+			keys[entry.Key] = len(f.Entries)
+		}
+		f.Entries = append(f.Entries, entry)
+	}
+	return nil
+}
+
+// parseUsageEntry 解析一行 transcript，只保留带 usage 与时间戳的 assistant 消息
+func parseUsageEntry(line string) (UsageEntry, bool) {
+	// 大部分行不是 assistant 消息，先做廉价的字符串过滤
+	if !strings.Contains(line, `"usage"`) {
+		return UsageEntry{}, false
+	}
+	msg := parseTranscriptLine(line)
+	if msg == nil || msg.Type != "assistant" || msg.Message == nil || msg.Message.Usage == nil {
+		return UsageEntry{}, false
+	}
+	at := msg.time()
+	if at.IsZero() {
+		return UsageEntry{}, false
+	}
+
+	usage := msg.Message.Usage
+	write5m, write1h := usage.CacheCreationInputTokens, 0
+	if usage.CacheCreation != nil {
+		write5m = usage.CacheCreation.Ephemeral5mInputTokens
+		write1h = usage.CacheCreation.Ephemeral1hInputTokens
+	}
+
+	entry := UsageEntry{
+		Key:          msg.dedupKey(),
+		Time:         at,
+		SessionID:    msg.SessionID,
+		Project:      msg.Cwd,
+		Model:        msg.Message.Model,
+		Input:        usage.InputTokens,
+		Output:       usage.OutputTokens,
+		CacheWrite5m: write5m,
+		CacheWrite1h: write1h,
+		CacheRead:    usage.CacheReadInputTokens,
+	}
+	// 请求失败时写入的 <synthetic> 消息没有用量
+	return entry, entry.Tokens() > 0
+}
+
+// listTranscriptFiles 递归列出所有 *.jsonl 文件及其大小与修改时间
+func listTranscriptFiles(dirs []string) map[string]transcriptFile {
+	files := make(map[string]transcriptFile)
+	for _, dir := range dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".jsonl") {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				files[path] = transcriptFile{size: info.Size(), modTime: info.ModTime()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// apiErrorStatusPattern 匹配错误消息文本开头的 HTTP 状态码，如 "API Error: 429 {...}"
+var apiErrorStatusPattern = regexp.MustCompile(`API Error: (\d{3})`)
+
+// APIErrorsSegment 显示会话中 API 错误（429、529 等）的次数与距最后一次错误的时间
+type APIErrorsSegment struct {
+	Options config.APIErrorsOptions
+}
+
+// apiError transcript 中的一次 API 错误，status 为 0 表示未知状态码
+type apiError struct {
+	status int
+	at     time.Time
+}
+
+func (s *APIErrorsSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	apiErrors := parseAPIErrors(readTranscriptLines(input.TranscriptPath))
+	if len(apiErrors) == 0 {
+		return SegmentData{}
+	}
+
+	last := apiErrors[len(apiErrors)-1]
+	var since time.Duration
+	if !last.at.IsZero() {
+		since = time.Since(last.at)
+		// 安静期过后不再提醒
+		quiet := s.Options.QuietMinutes
+		if quiet <= 0 {
+			quiet = config.DefaultAPIErrorsOptions().QuietMinutes
+		}
+		if since > time.Duration(quiet)*time.Minute {
+			return SegmentData{}
+		}
+	}
+
+	counts := make(map[int]int)
+	for _, e := range apiErrors {
+		counts[e.status]++
+	}
+	statuses := make([]int, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	parts := make([]string, len(statuses))
+	for i, status := range statuses {
+		label := "err"
+		if status != 0 {
+			label = strconv.Itoa(status)
+		}
+		parts[i] = fmt.Sprintf("%d×%s", counts[status], label)
+	}
+	primary := strings.Join(parts, " ")
+
+	metadata := map[string]string{
+		"count":       fmt.Sprintf("%d", len(apiErrors)),
+		"last_status": fmt.Sprintf("%d", last.status),
+	}
+	if !last.at.IsZero() {
+		metadata["last_error"] = last.at.Format(time.RFC3339)
+		if since < time.Minute {
+			primary += " · just now"
+		} else {
+			primary += " · " + formatDuration(since) + " ago"
+		}
+	}
+
+	return SegmentData{
+		Primary:  primary,
+		Metadata: metadata,
+	}
+}
+
+// parseAPIErrors 收集主会话中的 API 错误：重试时写入的 system api_error 条目，
+// 以及重试耗尽后写入的 isApiErrorMessage 消息。
+// 同一请求的多次重试与最终的错误消息共享 parentUuid，只计一次，状态码与时间以最后一条为准
+func parseAPIErrors(lines []string) []apiError {
+	var apiErrors []apiError
+	index := make(map[string]int)
+	for _, line := range lines {
+		if !strings.Contains(line, `"api_error"`) && !strings.Contains(line, `"isApiErrorMessage":true`) {
+			continue
+		}
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.IsSidechain {
+			continue
+		}
+
+		var e apiError
+		switch {
+		case msg.Type == "system" && msg.Subtype == "api_error":
+			var detail struct {
+				Status int `json:"status"`
+			}
+			json.Unmarshal(msg.Error, &detail)
+			e = apiError{status: detail.Status, at: msg.time()}
+		case msg.IsAPIError && msg.Message != nil:
+			e = apiError{status: apiErrorStatus(msg.Message), at: msg.time()}
+		default:
+			continue
+		}
+
+		key := msg.ParentUUID
+		if key == "" {
+			key = msg.RequestID
+		}
+		if i, ok := index[key]; ok && key != "" {
+			apiErrors[i] = e
+			continue
+		}
+		if key != "" {
+			index[key] = len(apiErrors)
+		}
+		apiErrors = append(apiErrors, e)
+	}
+	return apiErrors
+}
+
+// apiErrorStatus 从错误消息文本中提取状态码
+func apiErrorStatus(content *MessageContent) int {
+	var text string
+	if len(content.Content) > 0 && content.Content[0] == '"' {
+		json.Unmarshal(content.Content, &text)
+	}
+	for _, block := range content.blocks() {
+		if block.Type == "text" {
+			text = block.Text
+			break
+		}
+	}
+
+	if m := apiErrorStatusPattern.FindStringSubmatch(text); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	if strings.Contains(strings.ToLower(text), "overloaded") {
+		return 529
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package segment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// billingBlockDuration 订阅额度按 5 小时滚动窗口重置
+const billingBlockDuration = 5 * time.Hour
+
+// billingBlockLookback 计算当前窗口时回看的用量历史
+// 窗口首尾相接，只有连续一整天每 5 小时内都有消息时，更早的历史才会影响当前窗口的起点
+const billingBlockLookback = 24 * time.Hour
+
+// BlockSegment 显示当前 5 小时计费窗口内的费用、token、已用/剩余时间以及按当前速度预计的窗口总花费
+type BlockSegment struct {
+	Pricing map[string]config.ModelPricing
+}
+
+// billingBlock 由本地 transcript 时间戳推导出的计费窗口
+type billingBlock struct {
+	start   time.Time // 第一条消息所在的整点
+	first   time.Time // 窗口内第一条消息的时间
+	entries []UsageEntry
+}
+
+func (b *billingBlock) end() time.Time {
+	return b.start.Add(billingBlockDuration)
+}
+
+func (s *BlockSegment) Collect(input *config.InputData) SegmentData {
+	now := time.Now()
+	block := activeBillingBlock(LoadUsageEntries(now.Add(-billingBlockLookback)), now)
+	if block == nil {
+		return SegmentData{}
+	}
+
+	var cost float64
+	var tokens int
+	for _, entry := range block.entries {
+		cost += entry.Cost(s.Pricing)
+		tokens += entry.Tokens()
+	}
+
+	elapsed := now.Sub(block.start)
+	remaining := block.end().Sub(now)
+
+	// 按窗口内第一条消息以来的平均速度推算到窗口结束
+	active := now.Sub(block.first)
+	if active < time.Minute {
+		active = time.Minute
+	}
+	projected := cost + cost/active.Minutes()*remaining.Minutes()
+
+	return SegmentData{
+		Primary: fmt.Sprintf("$%.2f · %s tok · %s (%s left) · ~$%.2f",
+			cost, formatTokenCount(tokens), formatDuration(elapsed), formatDuration(remaining), projected),
+		Metadata: map[string]string{
+			"start":     block.start.Format(time.RFC3339),
+			"end":       block.end().Format(time.RFC3339),
+			"cost":      fmt.Sprintf("%.4f", cost),
+			"tokens":    fmt.Sprintf("%d", tokens),
+			"projected": fmt.Sprintf("%.4f", projected),
+			"remaining": fmt.Sprintf("%.0f", remaining.Seconds()),
+			"percent":   formatPercentValue(elapsed.Seconds() / billingBlockDuration.Seconds() * 100),
+		},
+	}
+}
+
+// billingBlocks 按时间顺序切分计费窗口：窗口起点取第一条消息所在的整点，
+// 消息超出当前窗口时开启新窗口，因此中间空闲的时段不属于任何窗口
+func billingBlocks(entries []UsageEntry) []*billingBlock {
+	var blocks []*billingBlock
+	var current *billingBlock
+
+	for _, entry := range entries {
+		if current == nil || !entry.Time.Before(current.end()) {
+			current = &billingBlock{
+				start: entry.Time.Truncate(time.Hour),
+				first: entry.Time,
+			}
+			blocks = append(blocks, current)
+		}
+		current.entries = append(current.entries, entry)
+	}
+	return blocks
+}
+
+// activeBillingBlock 返回包含 now 的计费窗口，没有进行中的窗口时返回 nil
+func activeBillingBlock(entries []UsageEntry, now time.Time) *billingBlock {
+	blocks := billingBlocks(entries)
+	if len(blocks) == 0 {
+		return nil
+	}
+	last := blocks[len(blocks)-1]
+	if now.Before(last.start) || !now.Before(last.end()) {
+		return nil
+	}
+	return last
+}
@@ -0,0 +1,61 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// 每次渲染都是独立进程，需要跨进程复用的结果以 JSON 形式落盘到缓存目录
+
+// readCache 读取缓存文件并解码到 v，不存在或格式错误时返回 false
+func readCache(name string, v any) bool {
+	data, err := os.ReadFile(filepath.Join(config.CacheDir(), name))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// writeCache 原子写入缓存文件（先写临时文件再 rename），避免并发渲染读到半截内容
+func writeCache(name string, v any) error {
+	dir := config.CacheDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// cacheKey 根据任意字符串生成稳定的短 key，用于拼接缓存文件名
+func cacheKey(s string) string {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,114 @@
+package segment
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// Prompt cache 的有效期，命中或写入都会刷新
+const (
+	promptCacheTTL     = 5 * time.Minute
+	promptCacheTTLLong = time.Hour
+)
+
+// CacheSegment 显示 prompt cache 命中率与缓存过期倒计时
+type CacheSegment struct{}
+
+func (s *CacheSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	file, err := os.Open(input.TranscriptPath)
+	if err != nil {
+		return SegmentData{}
+	}
+	defer file.Close()
+
+	lines := readAllLines(file)
+
+	// 最近一轮从最后一条用户提示词开始
+	turnStart := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		if msg := parseTranscriptLine(lines[i]); msg != nil && msg.isUserPrompt() {
+			turnStart = i
+			break
+		}
+	}
+
+	session := mainThreadUsages(lines)
+	if len(session) == 0 {
+		return SegmentData{}
+	}
+	sessionRatio, ok := cacheHitRatio(session)
+	if !ok {
+		return SegmentData{}
+	}
+	turnRatio, ok := cacheHitRatio(mainThreadUsages(lines[turnStart:]))
+	if !ok {
+		turnRatio = sessionRatio
+	}
+
+	metadata := map[string]string{
+		"hit_ratio":         formatPercentValue(turnRatio),
+		"session_hit_ratio": formatPercentValue(sessionRatio),
+		"percent":           formatPercentValue(turnRatio),
+		"percent_scale":     "higher_is_better",
+	}
+	primary := fmt.Sprintf("%.0f%% (%.0f%%)", turnRatio, sessionRatio)
+
+	last := session[len(session)-1]
+	lastAt := last.time()
+	if lastAt.IsZero() {
+		return SegmentData{Primary: primary, Metadata: metadata}
+	}
+
+	ttl := promptCacheTTL
+	if creation := last.Message.Usage.CacheCreation; creation != nil && creation.Ephemeral1hInputTokens > 0 {
+		ttl = promptCacheTTLLong
+	}
+	remaining := ttl - time.Since(lastAt)
+	if remaining <= 0 {
+		metadata["ttl_remaining"] = "0"
+		return SegmentData{Primary: primary + " · cold", Metadata: metadata}
+	}
+
+	metadata["ttl_remaining"] = fmt.Sprintf("%.0f", remaining.Seconds())
+	return SegmentData{
+		Primary:  fmt.Sprintf("%s · warm %s", primary, formatCountdown(remaining)),
+		Metadata: metadata,
+	}
+}
+
+// mainThreadUsages 返回主会话去重后的 assistant 消息，子代理使用独立的缓存，不计入命中率
+func mainThreadUsages(lines []string) []*TranscriptMessage {
+	var messages []*TranscriptMessage
+	for _, msg := range assistantUsages(lines) {
+		if !msg.IsSidechain {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// cacheHitRatio 计算 cache_read / (input + cache_creation + cache_read)，没有任何输入 token 时返回 false
+func cacheHitRatio(messages []*TranscriptMessage) (float64, bool) {
+	var read, total int
+	for _, msg := range messages {
+		usage := msg.Message.Usage
+		read += usage.CacheReadInputTokens
+		total += usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(read) / float64(total) * 100, true
+}
+
+// formatCountdown 格式化为 m:ss
+func formatCountdown(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
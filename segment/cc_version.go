@@ -0,0 +1,49 @@
+package segment
+
+import (
+	"github.com/WAY29/cchline/config"
+)
+
+// testedClaudeCodeMajors 已验证过 transcript 与状态栏输入格式的 Claude Code 主版本
+var testedClaudeCodeMajors = map[int]bool{
+	1: true,
+	2: true,
+}
+
+// CCVersionSegment 显示 Claude Code 版本，低于团队要求的最低版本或主版本未经验证时告警
+type CCVersionSegment struct {
+	Options config.CCVersionOptions
+}
+
+func (s *CCVersionSegment) Collect(input *config.InputData) SegmentData {
+	if input.Version == "" {
+		return SegmentData{}
+	}
+
+	metadata := map[string]string{
+		"version": input.Version,
+	}
+	version, ok := config.ParseSemVer(input.Version)
+	if !ok {
+		return SegmentData{Primary: input.Version, Metadata: metadata}
+	}
+
+	if min, ok := config.ParseSemVer(s.Options.MinVersion); ok && config.CompareSemVer(version, min) < 0 {
+		metadata["warning"] = "outdated"
+		metadata["min_version"] = min.String()
+		return SegmentData{
+			Primary:  input.Version + " ⚠ < " + min.String(),
+			Metadata: metadata,
+		}
+	}
+
+	if !testedClaudeCodeMajors[version.Major] {
+		metadata["warning"] = "untested"
+		return SegmentData{
+			Primary:  input.Version + " ⚠ untested",
+			Metadata: metadata,
+		}
+	}
+
+	return SegmentData{Primary: input.Version, Metadata: metadata}
+}
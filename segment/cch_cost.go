@@ -22,14 +22,16 @@ func (s *CCHCostSegment) Collect(input *config.InputData) SegmentData {
 	}
 
 	// Format: $1.50/$10
-	var primary string
 	if stats.DailyQuota > 0 {
-		primary = fmt.Sprintf("$%.2f/$%.0f", stats.TodayCost, stats.DailyQuota)
-	} else {
-		primary = fmt.Sprintf("$%.2f", stats.TodayCost)
+		return SegmentData{
+			Primary: fmt.Sprintf("$%.2f/$%.0f", stats.TodayCost, stats.DailyQuota),
+			Metadata: map[string]string{
+				"percent": formatPercentValue(stats.TodayCost / stats.DailyQuota * 100),
+			},
+		}
 	}
 
 	return SegmentData{
-		Primary: primary,
+		Primary: fmt.Sprintf("$%.2f", stats.TodayCost),
 	}
 }
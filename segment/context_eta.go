@@ -0,0 +1,121 @@
+package segment
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// ContextEtaSegment 根据最近几轮的上下文增长速度估算距离自动压缩还剩多少轮/多长时间
+type ContextEtaSegment struct {
+	Options       config.ContextEtaOptions
+	ContextWindow config.ContextWindowOptions // 复用 context_window 的自动压缩阈值
+}
+
+// turnSample 一轮对话结束时的上下文占用
+type turnSample struct {
+	tokens int
+	at     time.Time
+}
+
+func (s *ContextEtaSegment) Collect(input *config.InputData) SegmentData {
+	samples := parseTurnSamples(input.TranscriptPath)
+
+	window := s.Options.Window
+	if window <= 0 {
+		window = config.DefaultContextEtaOptions().Window
+	}
+	// window 轮增长需要 window+1 个采样点
+	if len(samples) > window+1 {
+		samples = samples[len(samples)-window-1:]
+	}
+	if len(samples) < 2 {
+		return SegmentData{}
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	growth := last.tokens - first.tokens
+	if growth <= 0 {
+		return SegmentData{}
+	}
+	perTurn := float64(growth) / float64(len(samples)-1)
+
+	threshold := s.ContextWindow.AutoCompactThreshold
+	if threshold <= 0 || threshold > 100 {
+		threshold = config.DefaultContextWindowOptions().AutoCompactThreshold
+	}
+	compactLimit := int(float64(getModelContextLimit(input.Model.ID)) * threshold / 100)
+	remaining := compactLimit - last.tokens
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	turnsLeft := int(math.Ceil(float64(remaining) / perTurn))
+	metadata := map[string]string{
+		"tokens_per_turn": fmt.Sprintf("%.0f", perTurn),
+		"turns_left":      fmt.Sprintf("%d", turnsLeft),
+		"remaining":       fmt.Sprintf("%d", remaining),
+	}
+
+	// 缺少时间戳时只显示轮数
+	elapsed := last.at.Sub(first.at)
+	if first.at.IsZero() || last.at.IsZero() || elapsed < time.Minute {
+		return SegmentData{
+			Primary:  fmt.Sprintf("~%d turns to compact", turnsLeft),
+			Metadata: metadata,
+		}
+	}
+
+	perMinute := float64(growth) / elapsed.Minutes()
+	minutesLeft := time.Duration(float64(remaining) / perMinute * float64(time.Minute))
+	metadata["tokens_per_minute"] = fmt.Sprintf("%.0f", perMinute)
+	metadata["minutes_left"] = fmt.Sprintf("%.0f", minutesLeft.Minutes())
+
+	return SegmentData{
+		Primary:  fmt.Sprintf("~%d turns / %s to compact", turnsLeft, formatDuration(minutesLeft)),
+		Metadata: metadata,
+	}
+}
+
+// parseTurnSamples 按用户提示词切分轮次，取每轮最后一条 assistant 消息的上下文占用
+// 发生压缩后之前的采样不再有参考意义，重新开始统计
+func parseTurnSamples(path string) []turnSample {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var samples []turnSample
+	var current turnSample
+	for _, line := range readAllLines(file) {
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.IsSidechain {
+			continue
+		}
+
+		switch {
+		case msg.isCompactBoundary() || msg.IsCompactSummary:
+			samples = nil
+			current = turnSample{}
+		case msg.isUserPrompt():
+			if current.tokens > 0 {
+				samples = append(samples, current)
+			}
+			current = turnSample{}
+		case msg.Type == "assistant" && msg.Message != nil && msg.Message.Usage != nil:
+			current = turnSample{tokens: msg.Message.Usage.displayTokens(), at: msg.time()}
+		}
+	}
+	if current.tokens > 0 {
+		samples = append(samples, current)
+	}
+
+	return samples
+}
@@ -7,64 +7,148 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/WAY29/cchline/config"
 )
 
-type ContextWindowSegment struct{}
+type ContextWindowSegment struct {
+	Options config.ContextWindowOptions
+}
 
 func (s *ContextWindowSegment) Collect(input *config.InputData) SegmentData {
 	contextLimit := getModelContextLimit(input.Model.ID)
 
+	threshold := s.Options.AutoCompactThreshold
+	if threshold <= 0 || threshold > 100 {
+		threshold = config.DefaultContextWindowOptions().AutoCompactThreshold
+	}
+	compactLimit := int(float64(contextLimit) * threshold / 100)
+
 	// 解析 transcript 获取 token 使用量
-	totalTokens := parseTranscriptUsage(input.TranscriptPath)
+	usage := parseTranscriptUsage(input.TranscriptPath)
+	totalTokens := usage.tokens
+
+	metadata := map[string]string{
+		"tokens":            fmt.Sprintf("%d", totalTokens),
+		"limit":             fmt.Sprintf("%d", contextLimit),
+		"compact_threshold": fmt.Sprintf("%d", compactLimit),
+		"compactions":       fmt.Sprintf("%d", usage.compactions),
+	}
 
-	if totalTokens == 0 {
+	if totalTokens == 0 && !usage.compacted {
 		// 无数据时显示 "-"
+		metadata["tokens"] = "-"
 		return SegmentData{
-			Primary: "- · - tokens",
-			Metadata: map[string]string{
-				"tokens": "-",
-				"limit":  fmt.Sprintf("%d", contextLimit),
-			},
+			Primary:  "- · - tokens",
+			Metadata: metadata,
 		}
 	}
 
 	// 计算使用率
 	percentage := float64(totalTokens) / float64(contextLimit) * 100
-
-	// 格式化百分比
-	var percentageStr string
-	if percentage == float64(int(percentage)) {
-		percentageStr = fmt.Sprintf("%.0f%%", percentage)
-	} else {
-		percentageStr = fmt.Sprintf("%.1f%%", percentage)
+	compactPercentage := float64(totalTokens) / float64(compactLimit) * 100
+	remaining := compactLimit - totalTokens
+	if remaining < 0 {
+		remaining = 0
 	}
 
+	metadata["limit_percent"] = formatPercentValue(percentage)
+	metadata["compact_percent"] = formatPercentValue(compactPercentage)
+	metadata["remaining"] = fmt.Sprintf("%d", remaining)
+
 	// 格式化 token 数量
 	tokensStr := formatTokenCount(totalTokens)
 
+	var primary string
+	if s.Options.CompactAware {
+		metadata["percent"] = metadata["compact_percent"]
+		primary = fmt.Sprintf("%s · %s tokens · %s left", formatPercent(compactPercentage), tokensStr, formatTokenCount(remaining))
+	} else {
+		metadata["percent"] = metadata["limit_percent"]
+		primary = fmt.Sprintf("%s · %s tokens", formatPercent(percentage), tokensStr)
+	}
+
+	// 本轮新增的上下文 token
+	if s.Options.ShowDelta {
+		turn := currentTurn(readTranscriptLines(input.TranscriptPath))
+		if delta, ok := sessionDelta(input.SessionID, turn, "context_tokens", float64(totalTokens)); ok && delta != 0 {
+			metadata["delta"] = fmt.Sprintf("%.0f", delta)
+			primary += " (" + formatTokenDelta(int(delta)) + ")"
+		}
+	}
+
 	return SegmentData{
-		Primary: fmt.Sprintf("%s · %s tokens", percentageStr, tokensStr),
-		Metadata: map[string]string{
-			"tokens": fmt.Sprintf("%d", totalTokens),
-			"limit":  fmt.Sprintf("%d", contextLimit),
-		},
+		Primary:  primary,
+		Metadata: metadata,
 	}
 }
 
+// formatPercent 格式化百分比，整数时不显示小数
+func formatPercent(percentage float64) string {
+	if percentage == float64(int(percentage)) {
+		return fmt.Sprintf("%.0f%%", percentage)
+	}
+	return fmt.Sprintf("%.1f%%", percentage)
+}
+
+// formatPercentValue 格式化写入 metadata 的百分比数值（不带 %）
+func formatPercentValue(percentage float64) string {
+	return strconv.FormatFloat(percentage, 'f', 1, 64)
+}
+
 // TranscriptMessage 表示 transcript 中的消息结构
 type TranscriptMessage struct {
-	Type     string          `json:"type"`
-	UUID     string          `json:"uuid,omitempty"`
-	LeafUUID string          `json:"leafUuid,omitempty"`
-	Message  *MessageContent `json:"message,omitempty"`
+	Type             string          `json:"type"`
+	Subtype          string          `json:"subtype,omitempty"`
+	UUID             string          `json:"uuid,omitempty"`
+	ParentUUID       string          `json:"parentUuid,omitempty"`
+	LeafUUID         string          `json:"leafUuid,omitempty"`
+	Summary          string          `json:"summary,omitempty"` // summary 条目的会话摘要
+	IsCompactSummary bool            `json:"isCompactSummary,omitempty"`
+	IsMeta           bool            `json:"isMeta,omitempty"`
+	IsSidechain      bool            `json:"isSidechain,omitempty"`       // Task 子代理的消息
+	IsAPIError       bool            `json:"isApiErrorMessage,omitempty"` // 请求失败时写入的错误消息
+	Error            json.RawMessage `json:"error,omitempty"`             // system api_error 条目的错误详情
+	Timestamp        string          `json:"timestamp,omitempty"`
+	RequestID        string          `json:"requestId,omitempty"`
+	SessionID        string          `json:"sessionId,omitempty"`
+	Cwd              string          `json:"cwd,omitempty"`
+	Message          *MessageContent `json:"message,omitempty"`
 }
 
 // MessageContent 消息内容
 type MessageContent struct {
-	Usage *UsageData `json:"usage,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Model   string          `json:"model,omitempty"`
+	Content json.RawMessage `json:"content,omitempty"`
+	Usage   *UsageData      `json:"usage,omitempty"`
+}
+
+// ContentBlock 消息内容中的单个块，如 text、tool_use、tool_result
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id,omitempty"`
+	Text      string          `json:"text,omitempty"`        // text 块的文本
+	Thinking  string          `json:"thinking,omitempty"`    // thinking 块的思考内容
+	Name      string          `json:"name,omitempty"`        // tool_use 的工具名
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use 的参数
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result 对应的 tool_use
+	IsError   bool            `json:"is_error,omitempty"`    // tool_result 是否为错误
+}
+
+// blocks 解析消息内容块，字符串内容或解析失败时返回 nil
+func (c *MessageContent) blocks() []ContentBlock {
+	if len(c.Content) == 0 || c.Content[0] != '[' {
+		return nil
+	}
+	var blocks []ContentBlock
+	if err := json.Unmarshal(c.Content, &blocks); err != nil {
+		return nil
+	}
+	return blocks
 }
 
 // UsageData token 使用数据
@@ -73,6 +157,14 @@ type UsageData struct {
 	OutputTokens             int `json:"output_tokens"`
 	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+
+	CacheCreation *CacheCreationData `json:"cache_creation,omitempty"`
+}
+
+// CacheCreationData 按缓存时长拆分的缓存写入 token
+type CacheCreationData struct {
+	Ephemeral5mInputTokens int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
 }
 
 // displayTokens 计算显示用的 token 总数
@@ -81,32 +173,72 @@ func (u *UsageData) displayTokens() int {
 	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 }
 
-// parseTranscriptUsage 解析 transcript 文件获取 token 使用量
-func parseTranscriptUsage(transcriptPath string) int {
+// isCompactBoundary 判断是否为压缩边界（compact_boundary 系统消息）
+func (m *TranscriptMessage) isCompactBoundary() bool {
+	return m.Type == "system" && m.Subtype == "compact_boundary"
+}
+
+// time 解析消息时间戳，缺失或格式错误时返回零值
+func (m *TranscriptMessage) time() time.Time {
+	t, err := time.Parse(time.RFC3339Nano, m.Timestamp)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// isUserPrompt 判断是否为用户输入的提示词（排除 tool_result、meta、压缩摘要与子代理的任务提示）
+func (m *TranscriptMessage) isUserPrompt() bool {
+	if m.Type != "user" || m.IsMeta || m.IsCompactSummary || m.IsSidechain || m.Message == nil {
+		return false
+	}
+
+	content := m.Message.Content
+	if len(content) == 0 || content[0] == '"' {
+		return len(content) > 0
+	}
+
+	for _, block := range m.Message.blocks() {
+		if block.Type != "tool_result" {
+			return true
+		}
+	}
+	return false
+}
+
+// contextUsage transcript 中解析出的上下文使用情况
+type contextUsage struct {
+	tokens      int  // 最近一次 assistant 消息占用的上下文 token
+	compactions int  // 会话内发生的压缩次数
+	compacted   bool // 最近一次压缩之后尚无新的 assistant 消息，计数已归零
+}
+
+// parseTranscriptUsage 解析 transcript 文件获取上下文使用情况
+func parseTranscriptUsage(transcriptPath string) contextUsage {
 	if transcriptPath == "" {
-		return 0
+		return contextUsage{}
 	}
 
 	// 尝试从当前 transcript 文件解析
-	if usage := tryParseTranscriptFile(transcriptPath); usage > 0 {
+	if usage := tryParseTranscriptFile(transcriptPath); usage.tokens > 0 || usage.compacted {
 		return usage
 	}
 
 	// 如果文件不存在，尝试从项目历史中查找
 	if _, err := os.Stat(transcriptPath); os.IsNotExist(err) {
 		if usage := tryFindUsageFromProjectHistory(transcriptPath); usage > 0 {
-			return usage
+			return contextUsage{tokens: usage}
 		}
 	}
 
-	return 0
+	return contextUsage{}
 }
 
 // tryParseTranscriptFile 尝试解析 transcript 文件
-func tryParseTranscriptFile(path string) int {
+func tryParseTranscriptFile(path string) contextUsage {
 	file, err := os.Open(path)
 	if err != nil {
-		return 0
+		return contextUsage{}
 	}
 	defer file.Close()
 
@@ -121,7 +253,7 @@ func tryParseTranscriptFile(path string) int {
 	}
 
 	if len(lines) == 0 {
-		return 0
+		return contextUsage{}
 	}
 
 	// 检查最后一行是否是 summary
@@ -131,28 +263,42 @@ func tryParseTranscriptFile(path string) int {
 		if entry.Type == "summary" && entry.LeafUUID != "" {
 			// summary 情况：通过 leafUuid 查找 usage
 			projectDir := filepath.Dir(path)
-			return findUsageByLeafUUID(entry.LeafUUID, projectDir)
+			return contextUsage{tokens: findUsageByLeafUUID(entry.LeafUUID, projectDir)}
 		}
 	}
 
-	// 正常情况：从后往前找最后一条 assistant 消息
-	for i := len(lines) - 1; i >= 0; i-- {
-		line := strings.TrimSpace(lines[i])
-		if line == "" {
-			continue
-		}
-
-		var msg TranscriptMessage
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+	// 正常情况：按顺序扫描，以最后一条 assistant 消息为准，遇到压缩边界时归零
+	// 子代理有独立的上下文，其消息不计入主会话
+	var usage contextUsage
+	afterBoundary := false
+	for _, line := range lines {
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.IsSidechain {
 			continue
 		}
 
-		if msg.Type == "assistant" && msg.Message != nil && msg.Message.Usage != nil {
-			return msg.Message.Usage.displayTokens()
+		switch {
+		case msg.isCompactBoundary():
+			usage.compactions++
+			usage.tokens = 0
+			usage.compacted = true
+			afterBoundary = true
+		case msg.IsCompactSummary:
+			// 旧版本只写入压缩摘要消息而没有 compact_boundary
+			if !afterBoundary {
+				usage.compactions++
+			}
+			usage.tokens = 0
+			usage.compacted = true
+			afterBoundary = false
+		case msg.Type == "assistant" && msg.Message != nil && msg.Message.Usage != nil:
+			usage.tokens = msg.Message.Usage.displayTokens()
+			usage.compacted = false
+			afterBoundary = false
 		}
 	}
 
-	return 0
+	return usage
 }
 
 // findUsageByLeafUUID 通过 leafUUID 在项目目录中查找 usage
@@ -255,8 +401,8 @@ func tryFindUsageFromProjectHistory(transcriptPath string) int {
 
 	// 从最新的文件开始查找
 	for _, f := range files {
-		if usage := tryParseTranscriptFile(f.path); usage > 0 {
-			return usage
+		if usage := tryParseTranscriptFile(f.path); usage.tokens > 0 {
+			return usage.tokens
 		}
 	}
 
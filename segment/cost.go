@@ -2,18 +2,50 @@ package segment
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/WAY29/cchline/config"
 )
 
-type CostSegment struct{}
+type CostSegment struct {
+	Options config.CostOptions
+}
 
 func (s *CostSegment) Collect(input *config.InputData) SegmentData {
 	cost := input.Cost.TotalCostUSD
+	source := config.CostSourceInput
+
+	// 订阅用户与 CCH 等代理的 total_cost_usd 为 0 或不具参考意义，按 transcript 本地计算
+	if s.Options.Source == config.CostSourceLocal || s.Options.Source != config.CostSourceInput && cost == 0 {
+		cost, source = 0, config.CostSourceLocal
+		if input.TranscriptPath != "" {
+			if local, ok := transcriptCost(input.TranscriptPath, s.Options.Pricing); ok {
+				cost = local
+			}
+		}
+	}
+
 	if cost == 0 {
 		return SegmentData{}
 	}
+
+	primary := fmt.Sprintf("$%.2f", cost)
+	metadata := map[string]string{
+		"cost":   fmt.Sprintf("%.4f", cost),
+		"source": source,
+	}
+
+	// 本轮新增的费用
+	if s.Options.ShowDelta && input.TranscriptPath != "" {
+		turn := currentTurn(readTranscriptLines(input.TranscriptPath))
+		if delta, ok := sessionDelta(input.SessionID, turn, "cost", cost); ok && math.Abs(delta) >= 0.005 {
+			metadata["delta"] = fmt.Sprintf("%.4f", delta)
+			primary += " (" + formatCostDelta(delta) + ")"
+		}
+	}
+
 	return SegmentData{
-		Primary: fmt.Sprintf("$%.2f", cost),
+		Primary:  primary,
+		Metadata: metadata,
 	}
 }
@@ -0,0 +1,240 @@
+package segment
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// 环境类 segment 只读取环境变量与少量文件，不执行外部命令
+
+// PythonEnvSegment 显示激活的 virtualenv/conda 环境，未激活时查找 current_dir 向上最近的 .venv
+type PythonEnvSegment struct{}
+
+func (s *PythonEnvSegment) Collect(input *config.InputData) SegmentData {
+	if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+		return pythonEnvData(venvName(venv), pyvenvVersion(venv), "virtualenv")
+	}
+	if conda := os.Getenv("CONDA_DEFAULT_ENV"); conda != "" {
+		return pythonEnvData(conda, "", "conda")
+	}
+
+	for _, name := range []string{".venv", "venv"} {
+		if cfg := findUp(input.Workspace.CurrentDir, filepath.Join(name, "pyvenv.cfg")); cfg != "" {
+			venv := filepath.Dir(cfg)
+			return pythonEnvData(venvName(venv), pyvenvVersion(venv), "virtualenv")
+		}
+	}
+	return SegmentData{}
+}
+
+func pythonEnvData(name, version, kind string) SegmentData {
+	primary := name
+	if version != "" {
+		primary += " " + version
+	}
+	return SegmentData{
+		Primary: primary,
+		Metadata: map[string]string{
+			"name":    name,
+			"version": version,
+			"kind":    kind,
+		},
+	}
+}
+
+// venvName 返回虚拟环境名，.venv 这类通用目录名使用所在项目的目录名
+func venvName(path string) string {
+	name := filepath.Base(path)
+	switch name {
+	case ".venv", "venv", "env", ".env":
+		return filepath.Base(filepath.Dir(path))
+	}
+	return name
+}
+
+// pyvenvVersion 从 pyvenv.cfg 读取 Python 版本
+func pyvenvVersion(venv string) string {
+	values := readKeyValueFile(filepath.Join(venv, "pyvenv.cfg"), "=")
+	if version := values["version"]; version != "" {
+		return version
+	}
+	// uv 创建的环境使用 version_info
+	return values["version_info"]
+}
+
+// GoVersionSegment 显示 current_dir 所在模块 go.mod 中的 Go 版本，声明了 toolchain 时以其为准
+type GoVersionSegment struct{}
+
+func (s *GoVersionSegment) Collect(input *config.InputData) SegmentData {
+	goMod := findUp(input.Workspace.CurrentDir, "go.mod")
+	if goMod == "" {
+		return SegmentData{}
+	}
+	file, err := os.Open(goMod)
+	if err != nil {
+		return SegmentData{}
+	}
+	defer file.Close()
+
+	var goVersion, toolchain string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 去掉行尾注释，如 "go 1.22 // indirect"
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goVersion = fields[1]
+		case "toolchain":
+			toolchain = strings.TrimPrefix(fields[1], "go")
+		}
+	}
+	if goVersion == "" && toolchain == "" {
+		return SegmentData{}
+	}
+
+	primary := goVersion
+	if toolchain != "" {
+		primary = toolchain
+	}
+	return SegmentData{
+		Primary: primary,
+		Metadata: map[string]string{
+			"go":        goVersion,
+			"toolchain": toolchain,
+			"go_mod":    goMod,
+		},
+	}
+}
+
+// NodeVersionSegment 显示 current_dir 向上最近的 .nvmrc 或 package.json engines.node 声明的 Node 版本
+type NodeVersionSegment struct{}
+
+func (s *NodeVersionSegment) Collect(input *config.InputData) SegmentData {
+	for dir := input.Workspace.CurrentDir; dir != ""; dir = parentDir(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, ".nvmrc")); err == nil {
+			if version := strings.TrimSpace(string(data)); version != "" {
+				return nodeVersionData(version, ".nvmrc")
+			}
+		}
+
+		var pkg struct {
+			Engines struct {
+				Node string `json:"node"`
+			} `json:"engines"`
+		}
+		if readJSONFile(filepath.Join(dir, "package.json"), &pkg) && pkg.Engines.Node != "" {
+			return nodeVersionData(pkg.Engines.Node, "package.json")
+		}
+	}
+	return SegmentData{}
+}
+
+func nodeVersionData(version, source string) SegmentData {
+	return SegmentData{
+		Primary: version,
+		Metadata: map[string]string{
+			"version": version,
+			"source":  source,
+		},
+	}
+}
+
+// KubeContextSegment 显示 kubeconfig 中的 current-context
+type KubeContextSegment struct{}
+
+func (s *KubeContextSegment) Collect(input *config.InputData) SegmentData {
+	paths := filepath.SplitList(os.Getenv("KUBECONFIG"))
+	if len(paths) == 0 {
+		paths = []string{filepath.Join(os.Getenv("HOME"), ".kube", "config")}
+	}
+
+	// KUBECONFIG 包含多个文件时，以第一个设置了 current-context 的为准
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if context := readKeyValueFile(path, ":")["current-context"]; context != "" {
+			context = strings.Trim(context, `"'`)
+			return SegmentData{
+				Primary:  context,
+				Metadata: map[string]string{"context": context, "kubeconfig": path},
+			}
+		}
+	}
+	return SegmentData{}
+}
+
+// CloudProfileSegment 显示 AWS_PROFILE 与 GOOGLE_CLOUD_PROJECT
+type CloudProfileSegment struct{}
+
+func (s *CloudProfileSegment) Collect(input *config.InputData) SegmentData {
+	var parts []string
+	metadata := make(map[string]string)
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		parts = append(parts, "aws:"+profile)
+		metadata["aws_profile"] = profile
+	}
+	if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" {
+		parts = append(parts, "gcp:"+project)
+		metadata["gcp_project"] = project
+	}
+	if len(parts) == 0 {
+		return SegmentData{}
+	}
+	return SegmentData{
+		Primary:  strings.Join(parts, " · "),
+		Metadata: metadata,
+	}
+}
+
+// findUp 从 dir 开始逐级向上查找 name，返回找到的完整路径
+func findUp(dir, name string) string {
+	for ; dir != ""; dir = parentDir(dir) {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// parentDir 返回上级目录，已到根目录时返回空
+func parentDir(dir string) string {
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return ""
+	}
+	return parent
+}
+
+// readKeyValueFile 读取 "key<sep>value" 形式的顶层配置行，忽略缩进的行与注释
+func readKeyValueFile(path, sep string) map[string]string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, sep)
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return values
+}
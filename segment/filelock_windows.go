@@ -0,0 +1,18 @@
+//go:build windows
+
+package segment
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile 对 f 加排他锁，阻塞直到获得锁
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, new(windows.Overlapped))
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
@@ -0,0 +1,276 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// 修改文件的工具
+var fileEditTools = map[string]bool{
+	"Edit":         true,
+	"MultiEdit":    true,
+	"Write":        true,
+	"NotebookEdit": true,
+}
+
+// FilesSegment 显示本会话修改过的文件数与最近修改的文件
+type FilesSegment struct {
+	Options config.FilesOptions
+}
+
+func (s *FilesSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	base := input.Workspace.ProjectDir
+	if base == "" {
+		base = input.Workspace.CurrentDir
+	}
+
+	files := touchedFiles(readTranscriptLines(input.TranscriptPath), base)
+	if len(files) == 0 {
+		return SegmentData{}
+	}
+	latest := files[len(files)-1]
+
+	count := fmt.Sprintf("%d files", len(files))
+	if len(files) == 1 {
+		count = "1 file"
+	}
+	display := relativePath(base, latest)
+	metadata := map[string]string{
+		"count":  fmt.Sprintf("%d", len(files)),
+		"latest": latest,
+	}
+
+	if s.Options.MarkUncommitted {
+		if dirty, ok := uncommittedFiles(base, files); ok {
+			metadata["uncommitted"] = fmt.Sprintf("%d", len(dirty))
+			if len(dirty) > 0 {
+				count += fmt.Sprintf(" (%d dirty)", len(dirty))
+			}
+			if dirty[latest] {
+				display += "*"
+			}
+		}
+	}
+
+	return SegmentData{
+		Primary:  count + " · " + display,
+		Metadata: metadata,
+	}
+}
+
+// touchedFiles 按最后一次修改的顺序返回去重后的文件绝对路径
+func touchedFiles(lines []string, base string) []string {
+	lastEdit := make(map[string]int)
+	seq := 0
+	for _, line := range lines {
+		if !strings.Contains(line, `"tool_use"`) {
+			continue
+		}
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.Type != "assistant" || msg.Message == nil {
+			continue
+		}
+
+		for _, block := range msg.Message.blocks() {
+			if block.Type != "tool_use" || !fileEditTools[block.Name] {
+				continue
+			}
+			var params struct {
+				FilePath     string `json:"file_path"`
+				NotebookPath string `json:"notebook_path"`
+			}
+			if err := json.Unmarshal(block.Input, &params); err != nil {
+				continue
+			}
+			path := params.FilePath
+			if path == "" {
+				path = params.NotebookPath
+			}
+			if path == "" {
+				continue
+			}
+			if !filepath.IsAbs(path) {
+				dir := msg.Cwd
+				if dir == "" {
+					dir = base
+				}
+				path = filepath.Join(dir, path)
+			}
+			path = filepath.Clean(path)
+
+			lastEdit[path] = seq
+			seq++
+		}
+	}
+
+	// 按最后一次修改的先后排序，最后一个元素是最近修改的文件
+	files := sortedKeys(lastEdit)
+	sort.Slice(files, func(i, j int) bool {
+		return lastEdit[files[i]] < lastEdit[files[j]]
+	})
+	return files
+}
+
+// relativePath 返回相对 base 的路径，不在 base 之下时返回原路径
+func relativePath(base, path string) string {
+	if base == "" {
+		return path
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// gitStatusPaths 持久化的 git status 路径列表，失效条件与 isDirty 的缓存相同
+type gitStatusPaths struct {
+	IndexModTime    int64    `json:"index_mtime"`
+	IndexSize       int64    `json:"index_size"`
+	WorkTreeModTime int64    `json:"worktree_mtime"`
+	Head            string   `json:"head"`
+	CheckedAt       int64    `json:"checked_at"`
+	Paths           []string `json:"paths"`
+}
+
+// uncommittedFiles 通过 git status 找出 files 中仍有未提交修改（含未跟踪）的文件
+func uncommittedFiles(dir string, files []string) (map[string]bool, bool) {
+	repo, err := openGitRepo(dir)
+	if err != nil {
+		return nil, false
+	}
+	_, headSHA := repo.head()
+	paths, ok := repo.statusPaths(headSHA)
+	if !ok {
+		return nil, false
+	}
+
+	// 未跟踪的目录以 / 结尾，其中的文件都算未提交
+	dirty := make(map[string]bool)
+	var untrackedDirs []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, "/") {
+			untrackedDirs = append(untrackedDirs, path)
+		} else {
+			dirty[path] = true
+		}
+	}
+
+	// transcript 中的路径可能经过符号链接（如 macOS 的 /tmp → /private/tmp），两侧都解析后再比较
+	workTree, err := filepath.EvalSymlinks(repo.workTree)
+	if err != nil {
+		workTree = repo.workTree
+	}
+	result := make(map[string]bool)
+	for _, file := range files {
+		rel, err := filepath.Rel(workTree, resolveSymlinks(file))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		if dirty[rel] {
+			result[file] = true
+			continue
+		}
+		for _, prefix := range untrackedDirs {
+			if strings.HasPrefix(rel, prefix) {
+				result[file] = true
+				break
+			}
+		}
+	}
+	return result, true
+}
+
+// resolveSymlinks 解析路径所在目录中的符号链接，文件本身是符号链接时保留，与 git 跟踪的路径一致
+// 目录不存在（如文件所在目录已删除）时返回原路径
+func resolveSymlinks(path string) string {
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return path
+	}
+	return filepath.Join(dir, filepath.Base(path))
+}
+
+// statusPaths 返回有未提交修改的路径（相对仓库根目录），按 index 与工作区根目录的 mtime、HEAD 与过期时间缓存
+// 未跟踪的目录不展开，避免在大量未跟踪文件时拖慢渲染
+func (r *gitRepo) statusPaths(headSHA string) ([]string, bool) {
+	indexModTime, indexSize := r.indexStat()
+	workTreeModTime := r.workTreeModTime()
+
+	cacheName := "git-files-" + cacheKey(r.workTree) + ".json"
+	now := time.Now()
+
+	var cached gitStatusPaths
+	if readCache(cacheName, &cached) &&
+		cached.IndexModTime == indexModTime &&
+		cached.IndexSize == indexSize &&
+		cached.WorkTreeModTime == workTreeModTime &&
+		cached.Head == headSHA &&
+		now.Sub(time.Unix(0, cached.CheckedAt)) < gitStatusCacheTTL {
+		return cached.Paths, true
+	}
+
+	cmd := exec.Command("git", "status", "--porcelain=v2", "-z", "--untracked-files=normal")
+	cmd.Dir = r.workTree
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, false
+	}
+
+	paths := parsePorcelainV2(string(out))
+	// git status 可能顺带刷新 index，缓存 key 取刷新后的状态，否则下次渲染必然失效
+	indexModTime, indexSize = r.indexStat()
+	writeCache(cacheName, gitStatusPaths{
+		IndexModTime:    indexModTime,
+		IndexSize:       indexSize,
+		WorkTreeModTime: workTreeModTime,
+		Head:            headSHA,
+		CheckedAt:       now.UnixNano(),
+		Paths:           paths,
+	})
+	return paths, true
+}
+
+// parsePorcelainV2 解析 git status --porcelain=v2 -z 的输出，返回相对仓库根目录的路径
+func parsePorcelainV2(output string) []string {
+	var paths []string
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 2 {
+			continue
+		}
+		switch entry[0] {
+		case '1':
+			// 1 XY sub mH mI mW hH hI path
+			if fields := strings.SplitN(entry, " ", 9); len(fields) == 9 {
+				paths = append(paths, fields[8])
+			}
+		case '2':
+			// 2 XY sub mH mI mW hH hI Xscore path，随后一项是原路径
+			if fields := strings.SplitN(entry, " ", 10); len(fields) == 10 {
+				paths = append(paths, fields[9])
+			}
+			i++
+		case 'u':
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			if fields := strings.SplitN(entry, " ", 11); len(fields) == 11 {
+				paths = append(paths, fields[10])
+			}
+		case '?':
+			paths = append(paths, entry[2:])
+		}
+	}
+	return paths
+}
@@ -1,19 +1,152 @@
 package segment
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/WAY29/cchline/config"
 )
 
-type GitSegment struct{}
+// gitStatusCacheTTL git status 类缓存的默认有效期，git 段可通过 status_cache_seconds 调整
+// 修改工作区文件不会更新 index，也不一定更新目录 mtime，因此除了 index 与工作区根目录的 mtime 外还需要过期时间
+const gitStatusCacheTTL = 10 * time.Second
+
+// maxChildRepos 汇总子目录仓库时最多检查的仓库数
+const maxChildRepos = 32
+
+type GitSegment struct {
+	Options config.GitOptions
+}
 
 func (s *GitSegment) Collect(input *config.InputData) SegmentData {
 	dir := input.Workspace.CurrentDir
 
+	repo, err := openGitRepo(dir)
+	if errors.Is(err, errNotGitRepo) {
+		if s.Options.ScanChildRepos {
+			return collectChildRepos(dir, s.statusCacheTTL())
+		}
+		return SegmentData{} // 非 Git 仓库
+	}
+	if err != nil {
+		return collectGitByCommand(dir)
+	}
+
+	// 尚无提交的仓库（unborn HEAD）与无法直接解析的 HEAD 交给 git 命令处理，前者与之前一样隐藏
+	branch, headSHA := repo.head()
+	if branch == "" || headSHA == "" {
+		return collectGitByCommand(dir)
+	}
+
+	dirty := repo.isDirty(headSHA, s.statusCacheTTL())
+	ahead, behind := repo.aheadBehind(branch, headSHA)
+
+	result := branch
+	if dirty {
+		result += " *"
+	}
+	result += formatAheadBehind(ahead, behind)
+
+	metadata := map[string]string{
+		"branch": branch,
+		"dirty":  strconv.FormatBool(dirty),
+		"ahead":  strconv.Itoa(ahead),
+		"behind": strconv.Itoa(behind),
+	}
+
+	// 附加 worktree / submodule 上下文，如 "topic * [wt:review sub:libs/foo@app]"
+	var context []string
+	if s.Options.ShowWorktree {
+		if name := repo.worktreeName(); name != "" {
+			context = append(context, "wt:"+name)
+			metadata["worktree"] = name
+		}
+	}
+	if s.Options.ShowSubmodule {
+		if super, path := repo.superproject(); super != nil {
+			superName := filepath.Base(super.workTree)
+			context = append(context, "sub:"+path+"@"+superName)
+			metadata["submodule"] = path
+			metadata["superproject"] = superName
+		}
+	}
+	if len(context) > 0 {
+		result += " [" + strings.Join(context, " ") + "]"
+	}
+
+	return SegmentData{Primary: result, Metadata: metadata}
+}
+
+// statusCacheTTL 返回配置的 git status 缓存有效期，未配置时使用默认值
+func (s *GitSegment) statusCacheTTL() time.Duration {
+	if s.Options.StatusCacheSeconds <= 0 {
+		return gitStatusCacheTTL
+	}
+	return time.Duration(s.Options.StatusCacheSeconds) * time.Second
+}
+
+// collectChildRepos 汇总 dir 下一级子目录中的仓库，如 "5 repos · 2 dirty"
+func collectChildRepos(dir string, ttl time.Duration) SegmentData {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return SegmentData{}
+	}
+
+	var total int
+	var dirtyRepos []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if total >= maxChildRepos {
+			break
+		}
+
+		child := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(child, ".git")); err != nil {
+			continue
+		}
+		repo, err := openGitRepo(child)
+		if err != nil {
+			continue
+		}
+		total++
+
+		_, headSHA := repo.head()
+		if repo.isDirty(headSHA, ttl) {
+			dirtyRepos = append(dirtyRepos, entry.Name())
+		}
+	}
+
+	if total == 0 {
+		return SegmentData{}
+	}
+
+	result := fmt.Sprintf("%d repos", total)
+	if total == 1 {
+		result = "1 repo"
+	}
+	if len(dirtyRepos) > 0 {
+		result += fmt.Sprintf(" · %d dirty", len(dirtyRepos))
+	}
+
+	return SegmentData{
+		Primary: result,
+		Metadata: map[string]string{
+			"repos":       strconv.Itoa(total),
+			"dirty_repos": strings.Join(dirtyRepos, ","),
+		},
+	}
+}
+
+// collectGitByCommand 通过 git 命令获取状态，用于原生读取不支持的仓库
+func collectGitByCommand(dir string) SegmentData {
 	branch := execGit(dir, "rev-parse", "--abbrev-ref", "HEAD")
 	if branch == "" {
 		return SegmentData{} // 非 Git 仓库
@@ -26,14 +159,133 @@ func (s *GitSegment) Collect(input *config.InputData) SegmentData {
 	}
 
 	ahead, behind := getAheadBehind(dir)
+	return SegmentData{
+		Primary: result + formatAheadBehind(ahead, behind),
+		Metadata: map[string]string{
+			"branch": branch,
+			"dirty":  strconv.FormatBool(status != ""),
+			"ahead":  strconv.Itoa(ahead),
+			"behind": strconv.Itoa(behind),
+		},
+	}
+}
+
+func formatAheadBehind(ahead, behind int) string {
+	var result string
 	if ahead > 0 {
 		result += fmt.Sprintf(" ↑%d", ahead)
 	}
 	if behind > 0 {
 		result += fmt.Sprintf(" ↓%d", behind)
 	}
+	return result
+}
+
+// gitStatusCache 持久化的 git status 结果
+type gitStatusCache struct {
+	IndexModTime    int64  `json:"index_mtime"`
+	IndexSize       int64  `json:"index_size"`
+	WorkTreeModTime int64  `json:"worktree_mtime"`
+	Head            string `json:"head"`
+	CheckedAt       int64  `json:"checked_at"`
+	Dirty           bool   `json:"dirty"`
+}
+
+// isDirty 判断工作区是否有未提交修改
+// 只有这一步需要 git status，结果按 index 与工作区根目录的 mtime 缓存
+func (r *gitRepo) isDirty(headSHA string, ttl time.Duration) bool {
+	indexModTime, indexSize := r.indexStat()
+	workTreeModTime := r.workTreeModTime()
+
+	cacheName := "git-status-" + cacheKey(r.workTree) + ".json"
+	now := time.Now()
+
+	var cached gitStatusCache
+	if readCache(cacheName, &cached) &&
+		cached.IndexModTime == indexModTime &&
+		cached.IndexSize == indexSize &&
+		cached.WorkTreeModTime == workTreeModTime &&
+		cached.Head == headSHA &&
+		now.Sub(time.Unix(0, cached.CheckedAt)) < ttl {
+		return cached.Dirty
+	}
+
+	dirty := execGit(r.workTree, "status", "--porcelain") != ""
+	// git status 可能顺带刷新 index，缓存 key 取刷新后的状态，否则下次渲染必然失效
+	indexModTime, indexSize = r.indexStat()
+	writeCache(cacheName, gitStatusCache{
+		IndexModTime:    indexModTime,
+		IndexSize:       indexSize,
+		WorkTreeModTime: workTreeModTime,
+		Head:            headSHA,
+		CheckedAt:       now.UnixNano(),
+		Dirty:           dirty,
+	})
+	return dirty
+}
+
+// indexStat 返回 index 文件的修改时间与大小，用作缓存 key
+func (r *gitRepo) indexStat() (modTime, size int64) {
+	info, err := os.Stat(filepath.Join(r.gitDir, "index"))
+	if err != nil {
+		return 0, 0
+	}
+	return info.ModTime().UnixNano(), info.Size()
+}
+
+// workTreeModTime 返回工作区根目录的修改时间，根目录下新建或删除文件时会变化
+func (r *gitRepo) workTreeModTime() int64 {
+	info, err := os.Stat(r.workTree)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
+
+// aheadBehind 读取对象库计算与上游的差异，无法读取时回退到 git rev-list
+func (r *gitRepo) aheadBehind(branch, headSHA string) (ahead, behind int) {
+	upstream := r.upstreamRef(branch)
+	if upstream == "" || headSHA == "" {
+		return 0, 0
+	}
+	upstreamSHA := r.resolveRef(upstream)
+	if upstreamSHA == "" {
+		return 0, 0
+	}
 
-	return SegmentData{Primary: result}
+	// 结果只取决于两侧的提交，SHA 不变时直接复用，避免每次渲染都遍历历史
+	cacheName := "git-ahead-" + cacheKey(r.workTree) + ".json"
+	var cached gitAheadBehindCache
+	if readCache(cacheName, &cached) && cached.Head == headSHA && cached.Upstream == upstreamSHA {
+		return cached.Ahead, cached.Behind
+	}
+
+	store := newGitObjectStore(r.commonDir)
+	ahead, behind, err := store.aheadBehind(headSHA, upstreamSHA)
+	store.close()
+	if err != nil {
+		output := execGit(r.workTree, "rev-list", "--left-right", "--count", upstreamSHA+"..."+headSHA)
+		if output == "" {
+			return 0, 0
+		}
+		behind, ahead = parseLeftRightCount(output)
+	}
+
+	writeCache(cacheName, gitAheadBehindCache{
+		Head:     headSHA,
+		Upstream: upstreamSHA,
+		Ahead:    ahead,
+		Behind:   behind,
+	})
+	return ahead, behind
+}
+
+// gitAheadBehindCache 持久化的 ahead/behind 结果
+type gitAheadBehindCache struct {
+	Head     string `json:"head"`
+	Upstream string `json:"upstream"`
+	Ahead    int    `json:"ahead"`
+	Behind   int    `json:"behind"`
 }
 
 func execGit(dir string, args ...string) string {
@@ -51,10 +303,16 @@ func getAheadBehind(dir string) (ahead, behind int) {
 	if output == "" {
 		return 0, 0
 	}
+	behind, ahead = parseLeftRightCount(output)
+	return
+}
+
+// parseLeftRightCount 解析 git rev-list --left-right --count 的 "<left>\t<right>" 输出
+func parseLeftRightCount(output string) (left, right int) {
 	parts := strings.Fields(output)
 	if len(parts) == 2 {
-		behind, _ = strconv.Atoi(parts[0])
-		ahead, _ = strconv.Atoi(parts[1])
+		left, _ = strconv.Atoi(parts[0])
+		right, _ = strconv.Atoi(parts[1])
 	}
 	return
 }
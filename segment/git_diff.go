@@ -0,0 +1,160 @@
+package segment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// GitDiffSegment 显示当前分支相对基准分支的提交数与改动统计，如 "3c +210/−34 (7f)"
+type GitDiffSegment struct {
+	Options config.GitDiffOptions
+}
+
+func (s *GitDiffSegment) Collect(input *config.InputData) SegmentData {
+	repo, err := openGitRepo(input.Workspace.CurrentDir)
+	if err != nil {
+		return SegmentData{}
+	}
+
+	_, headSHA := repo.head()
+	if headSHA == "" {
+		return SegmentData{}
+	}
+
+	baseName, baseSHA := repo.diffBase(s.Options.BaseBranch)
+	if baseSHA == "" {
+		return SegmentData{}
+	}
+
+	stat := repo.diffStat(headSHA, baseSHA)
+	if stat.Commits == 0 && stat.Files == 0 {
+		return SegmentData{}
+	}
+
+	primary := fmt.Sprintf("%dc +%d/−%d (%df)", stat.Commits, stat.Insertions, stat.Deletions, stat.Files)
+	return SegmentData{
+		Primary: primary,
+		Metadata: map[string]string{
+			"base":       baseName,
+			"merge_base": stat.MergeBase,
+			"commits":    strconv.Itoa(stat.Commits),
+			"insertions": strconv.Itoa(stat.Insertions),
+			"deletions":  strconv.Itoa(stat.Deletions),
+			"files":      strconv.Itoa(stat.Files),
+		},
+	}
+}
+
+// diffBase 解析基准分支；未配置时依次尝试 origin/HEAD、origin/main、origin/master、main、master
+func (r *gitRepo) diffBase(configured string) (name, sha string) {
+	if configured != "" {
+		// 与 git rev-parse 的短名解析顺序一致
+		for _, ref := range []string{configured, "refs/" + configured, "refs/heads/" + configured, "refs/remotes/" + configured} {
+			if sha := r.resolveRef(ref); sha != "" {
+				return configured, sha
+			}
+		}
+		return configured, ""
+	}
+
+	if target := r.readLooseRef("refs/remotes/origin/HEAD"); strings.HasPrefix(target, "ref:") {
+		ref := strings.TrimSpace(strings.TrimPrefix(target, "ref:"))
+		if sha := r.resolveRef(ref); sha != "" {
+			return strings.TrimPrefix(ref, "refs/remotes/"), sha
+		}
+	}
+
+	for _, ref := range []string{"refs/remotes/origin/main", "refs/remotes/origin/master", "refs/heads/main", "refs/heads/master"} {
+		if sha := r.resolveRef(ref); sha != "" {
+			return strings.TrimPrefix(strings.TrimPrefix(ref, "refs/remotes/"), "refs/heads/"), sha
+		}
+	}
+	return "", ""
+}
+
+// gitDiffStat 持久化的分支差异统计
+type gitDiffStat struct {
+	Head         string `json:"head"`
+	Base         string `json:"base"`
+	IndexModTime int64  `json:"index_mtime"`
+	IndexSize    int64  `json:"index_size"`
+	CheckedAt    int64  `json:"checked_at"`
+	MergeBase    string `json:"merge_base"`
+	Commits      int    `json:"commits"`
+	Insertions   int    `json:"insertions"`
+	Deletions    int    `json:"deletions"`
+	Files        int    `json:"files"`
+}
+
+// diffStat 计算 HEAD 相对 base 的提交数以及工作区相对 merge-base 的 shortstat
+// 结果按 HEAD、base 与 index mtime 缓存，命中时不启动任何 git 进程
+func (r *gitRepo) diffStat(headSHA, baseSHA string) gitDiffStat {
+	indexModTime, indexSize := r.indexStat()
+	cacheName := "git-diff-" + cacheKey(r.workTree) + ".json"
+	now := time.Now()
+
+	var cached gitDiffStat
+	if readCache(cacheName, &cached) &&
+		cached.Head == headSHA &&
+		cached.Base == baseSHA &&
+		cached.IndexModTime == indexModTime &&
+		cached.IndexSize == indexSize &&
+		now.Sub(time.Unix(0, cached.CheckedAt)) < gitStatusCacheTTL {
+		return cached
+	}
+
+	stat := gitDiffStat{
+		Head:         headSHA,
+		Base:         baseSHA,
+		IndexModTime: indexModTime,
+		IndexSize:    indexSize,
+		CheckedAt:    now.UnixNano(),
+	}
+
+	store := newGitObjectStore(r.commonDir)
+	ahead, _, err := store.aheadBehind(headSHA, baseSHA)
+	store.close()
+	if err != nil {
+		ahead, _ = strconv.Atoi(execGit(r.workTree, "rev-list", "--count", baseSHA+"..HEAD"))
+	}
+	stat.Commits = ahead
+
+	if headSHA == baseSHA {
+		stat.MergeBase = headSHA
+	} else {
+		stat.MergeBase = execGit(r.workTree, "merge-base", baseSHA, "HEAD")
+	}
+	if stat.MergeBase != "" {
+		stat.Files, stat.Insertions, stat.Deletions = parseShortStat(execGit(r.workTree, "diff", "--shortstat", stat.MergeBase))
+	}
+
+	writeCache(cacheName, stat)
+	return stat
+}
+
+// parseShortStat 解析 "7 files changed, 210 insertions(+), 34 deletions(-)"
+func parseShortStat(output string) (files, insertions, deletions int) {
+	for _, part := range strings.Split(output, ",") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(fields[1], "file"):
+			files = n
+		case strings.HasPrefix(fields[1], "insertion"):
+			insertions = n
+		case strings.HasPrefix(fields[1], "deletion"):
+			deletions = n
+		}
+	}
+	return
+}
@@ -0,0 +1,341 @@
+package segment
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitRepo 直接读取 .git 目录的仓库句柄，避免每次渲染都 fork git 进程
+type gitRepo struct {
+	workTree  string // 工作区根目录
+	gitDir    string // 当前工作区的 git 目录（worktree/submodule 时不是 .git）
+	commonDir string // 共享的 git 目录（refs、objects、config 所在）
+
+	config      map[string]string
+	packedRefs  map[string]string
+	packedReady bool
+}
+
+var (
+	errNotGitRepo         = errors.New("not a git repository")
+	errUnsupportedGitRepo = errors.New("unsupported git repository layout")
+)
+
+// openGitRepo 从 dir 向上查找 .git，支持普通仓库、linked worktree 与 submodule 的 gitdir 文件
+// 遇到不支持的仓库格式（sha256、reftable）时返回 errUnsupportedGitRepo，由调用方回退到 git 命令
+func openGitRepo(dir string) (*gitRepo, error) {
+	if dir == "" {
+		return nil, errNotGitRepo
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errNotGitRepo
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				gitDir = readGitDirFile(dotGit)
+				if gitDir == "" {
+					return nil, errUnsupportedGitRepo
+				}
+			}
+			return newGitRepo(dir, gitDir)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, errNotGitRepo
+		}
+		dir = parent
+	}
+}
+
+func newGitRepo(workTree, gitDir string) (*gitRepo, error) {
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, errUnsupportedGitRepo
+	}
+
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		commonDir = filepath.Clean(commonDir)
+	}
+
+	repo := &gitRepo{
+		workTree:  workTree,
+		gitDir:    gitDir,
+		commonDir: commonDir,
+		config:    parseGitConfig(filepath.Join(commonDir, "config")),
+	}
+
+	// 仅支持默认的 sha1 对象格式与文件 refs 存储
+	if format := repo.config["extensions.objectformat"]; format != "" && format != "sha1" {
+		return nil, errUnsupportedGitRepo
+	}
+	if storage := repo.config["extensions.refstorage"]; storage != "" && storage != "files" {
+		return nil, errUnsupportedGitRepo
+	}
+
+	return repo, nil
+}
+
+// readGitDirFile 解析 "gitdir: <path>" 格式的 .git 文件
+func readGitDirFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return ""
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir)
+}
+
+// head 返回当前分支名与 HEAD 指向的提交，detached 时分支名为 "HEAD"
+func (r *gitRepo) head() (branch, sha string) {
+	data, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return "", ""
+	}
+	content := strings.TrimSpace(string(data))
+
+	if ref, ok := strings.CutPrefix(content, "ref:"); ok {
+		ref = strings.TrimSpace(ref)
+		return strings.TrimPrefix(ref, "refs/heads/"), r.resolveRef(ref)
+	}
+	if isHexSHA(content) {
+		return "HEAD", content
+	}
+	return "", ""
+}
+
+// resolveRef 解析 ref 到提交 SHA，依次查找 loose ref 与 packed-refs
+func (r *gitRepo) resolveRef(ref string) string {
+	for depth := 0; depth < 5; depth++ {
+		content := r.readLooseRef(ref)
+		if content == "" {
+			return r.packedRef(ref)
+		}
+		next, ok := strings.CutPrefix(content, "ref:")
+		if !ok {
+			if isHexSHA(content) {
+				return content
+			}
+			return ""
+		}
+		ref = strings.TrimSpace(next)
+	}
+	return ""
+}
+
+func (r *gitRepo) readLooseRef(ref string) string {
+	// 每个 worktree 独有的 ref 存在 gitDir，其余共享 ref 在 commonDir
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if err == nil {
+			return strings.TrimSpace(string(data))
+		}
+		if r.gitDir == r.commonDir {
+			break
+		}
+	}
+	return ""
+}
+
+func (r *gitRepo) packedRef(ref string) string {
+	if !r.packedReady {
+		r.packedReady = true
+		r.packedRefs = make(map[string]string)
+
+		file, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+		if err != nil {
+			return ""
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			// 跳过注释与 peeled 行（^sha）
+			if line == "" || line[0] == '#' || line[0] == '^' {
+				continue
+			}
+			sha, name, ok := strings.Cut(line, " ")
+			if ok && isHexSHA(sha) {
+				r.packedRefs[name] = sha
+			}
+		}
+	}
+	return r.packedRefs[ref]
+}
+
+// worktreeName 返回 linked worktree 的名称（.git/worktrees/<name>），主工作区返回空
+func (r *gitRepo) worktreeName() string {
+	if r.gitDir == r.commonDir {
+		return ""
+	}
+	if filepath.Base(filepath.Dir(r.gitDir)) != "worktrees" {
+		return ""
+	}
+	return filepath.Base(r.gitDir)
+}
+
+// superproject 若当前仓库是某个仓库的 submodule，返回父仓库与子模块在父仓库中的路径
+func (r *gitRepo) superproject() (*gitRepo, string) {
+	parent, err := openGitRepo(filepath.Dir(r.workTree))
+	if err != nil {
+		return nil, ""
+	}
+	rel, err := filepath.Rel(parent.workTree, r.workTree)
+	if err != nil {
+		return nil, ""
+	}
+	rel = filepath.ToSlash(rel)
+
+	// 只认 .gitmodules 中声明过的路径，避免把嵌套的独立仓库误判为 submodule
+	modules := parseGitConfig(filepath.Join(parent.workTree, ".gitmodules"))
+	for key, path := range modules {
+		if strings.HasPrefix(key, "submodule.") && strings.HasSuffix(key, ".path") && strings.Trim(path, "/") == rel {
+			return parent, rel
+		}
+	}
+	return nil, ""
+}
+
+// upstreamRef 根据 branch.<name>.remote/merge 与 remote fetch refspec 推导上游跟踪 ref
+func (r *gitRepo) upstreamRef(branch string) string {
+	if branch == "" || branch == "HEAD" {
+		return ""
+	}
+	remote := r.config["branch."+branch+".remote"]
+	merge := r.config["branch."+branch+".merge"]
+	if remote == "" || merge == "" {
+		return ""
+	}
+	if remote == "." {
+		return merge
+	}
+
+	if fetch := r.config["remote."+remote+".fetch"]; fetch != "" {
+		if dst, ok := mapRefspec(fetch, merge); ok {
+			return dst
+		}
+	}
+	return "refs/remotes/" + remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+}
+
+// mapRefspec 使用 fetch refspec（如 +refs/heads/*:refs/remotes/origin/*）映射远端 ref
+func mapRefspec(refspec, ref string) (string, bool) {
+	refspec = strings.TrimPrefix(refspec, "+")
+	src, dst, ok := strings.Cut(refspec, ":")
+	if !ok {
+		return "", false
+	}
+	srcPrefix, srcSuffix, srcGlob := strings.Cut(src, "*")
+	if !srcGlob {
+		if src == ref {
+			return dst, true
+		}
+		return "", false
+	}
+	if !strings.HasPrefix(ref, srcPrefix) || !strings.HasSuffix(ref, srcSuffix) || len(ref) < len(srcPrefix)+len(srcSuffix) {
+		return "", false
+	}
+	middle := ref[len(srcPrefix) : len(ref)-len(srcSuffix)]
+	return strings.Replace(dst, "*", middle, 1), true
+}
+
+// parseGitConfig 解析 git config 的常用子集，key 形如 "section.subsection.name"
+// section 与 name 不区分大小写，subsection 保持原样；同名 key 取第一个值
+func parseGitConfig(path string) map[string]string {
+	values := make(map[string]string)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndex(line, "]")
+			if end < 0 {
+				continue
+			}
+			header := strings.TrimSpace(line[1:end])
+			if name, sub, ok := strings.Cut(header, " "); ok {
+				sub = strings.Trim(strings.TrimSpace(sub), "\"")
+				section = strings.ToLower(name) + "." + sub
+			} else {
+				// 兼容旧式 [branch.main] 写法
+				if name, sub, ok := strings.Cut(header, "."); ok {
+					section = strings.ToLower(name) + "." + sub
+				} else {
+					section = strings.ToLower(header)
+				}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			// 无值的布尔 key
+			key, value = line, "true"
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = trimGitConfigValue(value)
+
+		full := section + "." + key
+		if _, exists := values[full]; !exists {
+			values[full] = value
+		}
+	}
+
+	return values
+}
+
+func trimGitConfigValue(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "\"") {
+		if end := strings.Index(value[1:], "\""); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if i := strings.IndexAny(value, "#;"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+func isHexSHA(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
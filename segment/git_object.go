@@ -0,0 +1,506 @@
+package segment
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"container/heap"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxAheadBehindWalk 计算 ahead/behind 时最多遍历的提交数，超出后交给 git rev-list
+const maxAheadBehindWalk = 10000
+
+// maxGitObjectSize 读取的对象大小上限，超出视为损坏，交给 git 命令处理
+// 只需要读取提交对象，正常情况下远小于这个值
+const maxGitObjectSize = 16 << 20
+
+var errGitObjectNotFound = errors.New("git object not found")
+
+// gitCommit 计算 ahead/behind 所需的提交信息
+type gitCommit struct {
+	parents []string
+	time    int64
+}
+
+// gitObjectStore 读取 loose object 与 packfile（idx v2）中的对象
+type gitObjectStore struct {
+	objectsDir string
+	packs      []*gitPack
+	packsReady bool
+	commits    map[string]*gitCommit
+}
+
+type gitPack struct {
+	idx   *os.File
+	pack  *os.File
+	count uint32
+}
+
+func newGitObjectStore(commonDir string) *gitObjectStore {
+	return &gitObjectStore{
+		objectsDir: filepath.Join(commonDir, "objects"),
+		commits:    make(map[string]*gitCommit),
+	}
+}
+
+func (s *gitObjectStore) close() {
+	for _, p := range s.packs {
+		p.idx.Close()
+		p.pack.Close()
+	}
+	s.packs = nil
+}
+
+// commit 读取并解析提交对象
+func (s *gitObjectStore) commit(sha string) (*gitCommit, error) {
+	if c, ok := s.commits[sha]; ok {
+		return c, nil
+	}
+
+	objType, data, err := s.readObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	if objType != "commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", sha, objType)
+	}
+
+	c := parseGitCommit(data)
+	s.commits[sha] = c
+	return c, nil
+}
+
+func parseGitCommit(data []byte) *gitCommit {
+	c := &gitCommit{}
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		// 头部以空行结束
+		if len(line) == 0 {
+			break
+		}
+		if sha, ok := bytes.CutPrefix(line, []byte("parent ")); ok {
+			c.parents = append(c.parents, string(sha))
+		} else if committer, ok := bytes.CutPrefix(line, []byte("committer ")); ok {
+			// committer Name <email> 1700000000 +0800
+			fields := strings.Fields(string(committer))
+			if len(fields) >= 2 {
+				c.time, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+	return c
+}
+
+// readObject 读取对象，优先 loose object，然后查找 packfile
+func (s *gitObjectStore) readObject(sha string) (string, []byte, error) {
+	if objType, data, err := s.readLooseObject(sha); err == nil {
+		return objType, data, nil
+	}
+
+	s.loadPacks()
+	raw, err := hex.DecodeString(sha)
+	if err != nil || len(raw) != 20 {
+		return "", nil, fmt.Errorf("invalid object id %q", sha)
+	}
+	for _, p := range s.packs {
+		offset, ok := p.find(raw)
+		if !ok {
+			continue
+		}
+		return s.readPackedObject(p, offset, 0)
+	}
+	return "", nil, errGitObjectNotFound
+}
+
+func (s *gitObjectStore) readLooseObject(sha string) (string, []byte, error) {
+	file, err := os.Open(filepath.Join(s.objectsDir, sha[:2], sha[2:]))
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	zr, err := zlib.NewReader(file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, maxGitObjectSize))
+	if err != nil {
+		return "", nil, err
+	}
+
+	// "<type> <size>\x00<content>"
+	nul := bytes.IndexByte(data, 0)
+	if nul < 0 {
+		return "", nil, errors.New("malformed loose object")
+	}
+	objType, _, _ := strings.Cut(string(data[:nul]), " ")
+	return objType, data[nul+1:], nil
+}
+
+func (s *gitObjectStore) loadPacks() {
+	if s.packsReady {
+		return
+	}
+	s.packsReady = true
+
+	matches, _ := filepath.Glob(filepath.Join(s.objectsDir, "pack", "*.idx"))
+	for _, idxPath := range matches {
+		if p := openGitPack(idxPath); p != nil {
+			s.packs = append(s.packs, p)
+		}
+	}
+}
+
+func openGitPack(idxPath string) *gitPack {
+	idx, err := os.Open(idxPath)
+	if err != nil {
+		return nil
+	}
+
+	// idx v2: "\377tOc" + version(2) + fanout[256]
+	var header [8 + 256*4]byte
+	if _, err := idx.ReadAt(header[:], 0); err != nil ||
+		!bytes.Equal(header[:4], []byte{0xff, 't', 'O', 'c'}) ||
+		binary.BigEndian.Uint32(header[4:8]) != 2 {
+		idx.Close()
+		return nil
+	}
+
+	pack, err := os.Open(strings.TrimSuffix(idxPath, ".idx") + ".pack")
+	if err != nil {
+		idx.Close()
+		return nil
+	}
+
+	return &gitPack{
+		idx:   idx,
+		pack:  pack,
+		count: binary.BigEndian.Uint32(header[8+255*4:]),
+	}
+}
+
+// find 在 idx 中二分查找对象，返回其在 pack 中的偏移
+func (p *gitPack) find(sha []byte) (int64, bool) {
+	const fanoutOffset = 8
+	const shaOffset = fanoutOffset + 256*4
+
+	var buf [20]byte
+	lo := uint32(0)
+	if sha[0] > 0 {
+		if _, err := p.idx.ReadAt(buf[:4], fanoutOffset+int64(sha[0]-1)*4); err != nil {
+			return 0, false
+		}
+		lo = binary.BigEndian.Uint32(buf[:4])
+	}
+	if _, err := p.idx.ReadAt(buf[:4], fanoutOffset+int64(sha[0])*4); err != nil {
+		return 0, false
+	}
+	hi := binary.BigEndian.Uint32(buf[:4])
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if _, err := p.idx.ReadAt(buf[:], shaOffset+int64(mid)*20); err != nil {
+			return 0, false
+		}
+		switch bytes.Compare(buf[:], sha) {
+		case 0:
+			return p.offset(mid)
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+func (p *gitPack) offset(i uint32) (int64, bool) {
+	offsetTable := int64(8+256*4) + int64(p.count)*24 // sha 表 + crc 表
+	var buf [8]byte
+	if _, err := p.idx.ReadAt(buf[:4], offsetTable+int64(i)*4); err != nil {
+		return 0, false
+	}
+	offset := binary.BigEndian.Uint32(buf[:4])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+
+	// 最高位置 1 表示偏移存放在 64 位大偏移表中
+	largeTable := offsetTable + int64(p.count)*4
+	if _, err := p.idx.ReadAt(buf[:], largeTable+int64(offset&0x7fffffff)*8); err != nil {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(buf[:])), true
+}
+
+var gitPackTypes = map[byte]string{1: "commit", 2: "tree", 3: "blob", 4: "tag"}
+
+// readPackedObject 读取 pack 中指定偏移的对象，递归解析 ofs/ref delta
+func (s *gitObjectStore) readPackedObject(p *gitPack, offset int64, depth int) (string, []byte, error) {
+	if depth > 64 {
+		return "", nil, errors.New("delta chain too deep")
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(p.pack, offset, 1<<62))
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	// 头部记录的大小：普通对象为对象本身，delta 对象为 delta 数据的大小
+	kind := (b >> 4) & 7
+	size := int64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		if b, err = r.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		if shift > 56 {
+			return "", nil, errors.New("malformed pack object header")
+		}
+		size |= int64(b&0x7f) << shift
+	}
+	if size > maxGitObjectSize {
+		return "", nil, fmt.Errorf("pack object too large (%d bytes)", size)
+	}
+
+	var baseType string
+	var base []byte
+	switch kind {
+	case 6: // OFS_DELTA
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = r.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = (rel+1)<<7 | int64(b&0x7f)
+		}
+		baseType, base, err = s.readPackedObject(p, offset-rel, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+	case 7: // REF_DELTA
+		var ref [20]byte
+		if _, err := io.ReadFull(r, ref[:]); err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = s.readObject(hex.EncodeToString(ref[:]))
+		if err != nil {
+			return "", nil, err
+		}
+	default:
+		objType, ok := gitPackTypes[kind]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown pack object type %d", kind)
+		}
+		data, err := inflate(r, size)
+		return objType, data, err
+	}
+
+	delta, err := inflate(r, size)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := applyGitDelta(base, delta)
+	return baseType, data, err
+}
+
+// inflate 解压 pack 中的对象数据，解压后的长度必须与头部记录的 size 一致
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(io.LimitReader(zr, size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != size {
+		return nil, errors.New("pack object size mismatch")
+	}
+	return data, nil
+}
+
+// applyGitDelta 将 git delta 指令应用到 base 上
+func applyGitDelta(base, delta []byte) ([]byte, error) {
+	errBad := errors.New("malformed delta")
+
+	readSize := func() (int, bool) {
+		size, shift := 0, 0
+		for len(delta) > 0 && shift <= 56 {
+			b := delta[0]
+			delta = delta[1:]
+			size |= int(b&0x7f) << shift
+			shift += 7
+			if b&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	srcSize, ok := readSize()
+	if !ok || srcSize != len(base) {
+		return nil, errBad
+	}
+	// 目标大小来自 delta 头部，先校验再按它分配内存
+	dstSize, ok := readSize()
+	if !ok || dstSize < 0 || dstSize > maxGitObjectSize {
+		return nil, errBad
+	}
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			// 插入指令：后续 op 个字节原样写入
+			n := int(op)
+			if n == 0 || n > len(delta) || len(out)+n > dstSize {
+				return nil, errBad
+			}
+			out = append(out, delta[:n]...)
+			delta = delta[n:]
+			continue
+		}
+
+		// 复制指令：从 base 拷贝 [offset, offset+size)
+		var offset, size int
+		for i := 0; i < 4; i++ {
+			if op&(1<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errBad
+				}
+				offset |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if op&(0x10<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errBad
+				}
+				size |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) || len(out)+size > dstSize {
+			return nil, errBad
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+
+	if len(out) != dstSize {
+		return nil, errBad
+	}
+	return out, nil
+}
+
+// 遍历提交时标记其可达来源
+const (
+	fromLocal    uint8 = 1
+	fromUpstream uint8 = 2
+	fromBoth           = fromLocal | fromUpstream
+)
+
+// commitQueue 按提交时间倒序的优先队列
+type commitQueue []commitQueueItem
+
+type commitQueueItem struct {
+	sha  string
+	time int64
+}
+
+func (q commitQueue) Len() int           { return len(q) }
+func (q commitQueue) Less(i, j int) bool { return q[i].time > q[j].time }
+func (q commitQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *commitQueue) Push(x any)        { *q = append(*q, x.(commitQueueItem)) }
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// aheadBehind 统计 local 有而 upstream 没有的提交数（ahead）及反向的提交数（behind）
+// 同时从两侧遍历到历史末端：按提交时间提前结束在时钟偏差或 rebase 后的乱序时间下会算错，
+// 没有 commit-graph 的代数信息时只有完整遍历才准确。结果由调用方按两侧 SHA 缓存
+func (s *gitObjectStore) aheadBehind(local, upstream string) (ahead, behind int, err error) {
+	if local == upstream {
+		return 0, 0, nil
+	}
+
+	flags := map[string]uint8{}
+	queue := &commitQueue{}
+
+	push := func(sha string, flag uint8) error {
+		old := flags[sha]
+		if old|flag == old {
+			return nil
+		}
+		c, err := s.commit(sha)
+		if err != nil {
+			return err
+		}
+		flags[sha] = old | flag
+		heap.Push(queue, commitQueueItem{sha: sha, time: c.time})
+		return nil
+	}
+
+	if err := push(local, fromLocal); err != nil {
+		return 0, 0, err
+	}
+	if err := push(upstream, fromUpstream); err != nil {
+		return 0, 0, err
+	}
+
+	for walked := 0; queue.Len() > 0; walked++ {
+		if walked > maxAheadBehindWalk {
+			return 0, 0, errors.New("history too large to walk")
+		}
+		item := heap.Pop(queue).(commitQueueItem)
+		c, err := s.commit(item.sha)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, parent := range c.parents {
+			if err := push(parent, flags[item.sha]); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	for _, f := range flags {
+		switch f {
+		case fromLocal:
+			ahead++
+		case fromUpstream:
+			behind++
+		}
+	}
+	return ahead, behind, nil
+}
@@ -0,0 +1,166 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// mcpToolPrefix MCP 工具名的前缀，完整格式为 mcp__<server>__<tool>
+const mcpToolPrefix = "mcp__"
+
+// mcpNameSanitizer Claude Code 生成工具名时会把服务器名中的其他字符替换为下划线
+var mcpNameSanitizer = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// MCPSegment 显示已配置的 MCP 服务器数量以及本会话实际调用过的服务器
+type MCPSegment struct{}
+
+// mcpSettings 配置文件中与 MCP 相关的字段
+type mcpSettings struct {
+	MCPServers             map[string]json.RawMessage `json:"mcpServers"`
+	DisabledMcpjsonServers []string                   `json:"disabledMcpjsonServers"`
+}
+
+func (s *MCPSegment) Collect(input *config.InputData) SegmentData {
+	project := input.Workspace.ProjectDir
+	if project == "" {
+		project = input.Workspace.CurrentDir
+	}
+
+	configured := configuredMCPServers(project)
+	var used []string
+	if input.TranscriptPath != "" {
+		used = usedMCPServers(readTranscriptLines(input.TranscriptPath))
+	}
+	if len(configured) == 0 && len(used) == 0 {
+		return SegmentData{}
+	}
+
+	known := make(map[string]bool, len(configured))
+	for _, name := range configured {
+		known[mcpNameSanitizer.ReplaceAllString(name, "_")] = true
+	}
+	// 调用过但未在配置中找到的服务器（如插件提供的）以 ? 标出
+	var unknown []string
+	labels := make([]string, len(used))
+	for i, name := range used {
+		labels[i] = name
+		if !known[name] {
+			unknown = append(unknown, name)
+			labels[i] += "?"
+		}
+	}
+
+	primary := fmt.Sprintf("%d servers", len(configured))
+	if len(configured) == 1 {
+		primary = "1 server"
+	}
+	if len(used) > 0 {
+		primary += " · used: " + strings.Join(labels, ", ")
+	}
+
+	return SegmentData{
+		Primary: primary,
+		Metadata: map[string]string{
+			"configured":   fmt.Sprintf("%d", len(configured)),
+			"servers":      strings.Join(configured, ","),
+			"used":         strings.Join(used, ","),
+			"unconfigured": strings.Join(unknown, ","),
+		},
+	}
+}
+
+// configuredMCPServers 汇总用户、项目与本地范围配置的 MCP 服务器名，按名称排序
+// 来源：~/.claude.json（用户范围与 projects 下的本地范围）、settings.json 以及项目的 .mcp.json
+func configuredMCPServers(project string) []string {
+	servers := make(map[string]bool)
+	disabled := make(map[string]bool)
+	add := func(settings mcpSettings) {
+		for name := range settings.MCPServers {
+			servers[name] = true
+		}
+		for _, name := range settings.DisabledMcpjsonServers {
+			disabled[name] = true
+		}
+	}
+
+	var claudeJSON struct {
+		mcpSettings
+		Projects map[string]mcpSettings `json:"projects"`
+	}
+	if readJSONFile(config.ClaudeJSONPath(), &claudeJSON) {
+		add(claudeJSON.mcpSettings)
+		if local, ok := claudeJSON.Projects[project]; ok && project != "" {
+			add(local)
+		}
+	}
+
+	settingsFiles := []string{config.SettingsPath()}
+	if project != "" {
+		settingsFiles = append(settingsFiles,
+			filepath.Join(project, ".claude", "settings.json"),
+			filepath.Join(project, ".claude", "settings.local.json"),
+		)
+	}
+	for _, path := range settingsFiles {
+		var settings mcpSettings
+		if readJSONFile(path, &settings) {
+			add(settings)
+		}
+	}
+
+	// 项目范围的 .mcp.json 服务器可以在设置中被禁用
+	if project != "" {
+		var mcpJSON mcpSettings
+		if readJSONFile(filepath.Join(project, ".mcp.json"), &mcpJSON) {
+			for name := range mcpJSON.MCPServers {
+				if !disabled[name] {
+					servers[name] = true
+				}
+			}
+		}
+	}
+
+	return sortedKeys(servers)
+}
+
+// usedMCPServers 从 transcript 的 mcp__<server>__<tool> 工具调用中提取服务器名，按首次调用的顺序返回
+func usedMCPServers(lines []string) []string {
+	var used []string
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		if !strings.Contains(line, `"`+mcpToolPrefix) {
+			continue
+		}
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.Type != "assistant" || msg.Message == nil {
+			continue
+		}
+		for _, block := range msg.Message.blocks() {
+			if block.Type != "tool_use" || !strings.HasPrefix(block.Name, mcpToolPrefix) {
+				continue
+			}
+			server, _, ok := strings.Cut(strings.TrimPrefix(block.Name, mcpToolPrefix), "__")
+			if !ok || server == "" || seen[server] {
+				continue
+			}
+			seen[server] = true
+			used = append(used, server)
+		}
+	}
+	return used
+}
+
+// readJSONFile 读取并解析 JSON 文件，文件不存在或格式错误时返回 false
+func readJSONFile(path string, v any) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
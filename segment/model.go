@@ -1,12 +1,20 @@
 package segment
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/WAY29/cchline/config"
 )
 
-type ModelSegment struct{}
+// ModelSegment 显示当前模型，实际回答的模型与所选模型不同时（回退或代理改写）一并显示
+type ModelSegment struct {
+	Options config.ModelOptions
+}
+
+// modelDateSuffix 模型 ID 末尾的日期版本，如 "-20250514"
+var modelDateSuffix = regexp.MustCompile(`-\d{8}$`)
 
 func (s *ModelSegment) Collect(input *config.InputData) SegmentData {
 	name := input.Model.DisplayName
@@ -14,7 +22,118 @@ func (s *ModelSegment) Collect(input *config.InputData) SegmentData {
 		name = input.Model.ID
 	}
 	name = simplifyModelName(name)
-	return SegmentData{Primary: name}
+	if input.TranscriptPath == "" {
+		return SegmentData{Primary: name}
+	}
+
+	// 会话中可能发生回退（如 Opus 降级到 Sonnet）或通过 /model 切换，以实际回答的模型为准
+	lines := readTranscriptLines(input.TranscriptPath)
+	answered, current := lastAnsweredModel(lines)
+	if answered == "" {
+		return SegmentData{Primary: name}
+	}
+
+	primary := name
+	metadata := map[string]string{
+		"answered_model": answered,
+	}
+	// /model 切换后、新模型回答之前，最后的回答仍来自旧模型，此时不算不一致；
+	// 代理改写的 ID（去掉日期、区域前缀等）视为同一模型
+	if current && input.Model.ID != "" && normalizeModelID(answered) != normalizeModelID(input.Model.ID) {
+		metadata["mismatch"] = "true"
+		primary = name + " ⇢ " + simplifyModelName(answered)
+	}
+
+	if s.Options.ShowSwitches {
+		switches := countModelSwitches(lines)
+		metadata["switches"] = fmt.Sprintf("%d", switches)
+		if switches == 1 {
+			primary += " · 1 switch"
+		} else if switches > 1 {
+			primary += fmt.Sprintf(" · %d switches", switches)
+		}
+	}
+
+	return SegmentData{
+		Primary:  primary,
+		Metadata: metadata,
+	}
+}
+
+// lastAnsweredModel 从末尾向前找到主会话最后一条模型回答，current 表示其后没有新的用户提示词
+func lastAnsweredModel(lines []string) (model string, current bool) {
+	current = true
+	for i := len(lines) - 1; i >= 0; i-- {
+		msg := parseTranscriptLine(lines[i])
+		if msg == nil {
+			continue
+		}
+		if msg.isUserPrompt() {
+			current = false
+			continue
+		}
+		if model := answeredModel(msg); model != "" {
+			return model, current
+		}
+	}
+	return "", false
+}
+
+// countModelSwitches 统计主会话中实际回答的模型发生变化的次数
+func countModelSwitches(lines []string) int {
+	var last string
+	switches := 0
+	for _, line := range lines {
+		if !strings.Contains(line, `"model"`) {
+			continue
+		}
+		msg := parseTranscriptLine(line)
+		if msg == nil {
+			continue
+		}
+		model := answeredModel(msg)
+		if model == "" {
+			continue
+		}
+		model = normalizeModelID(model)
+		if last != "" && model != last {
+			switches++
+		}
+		last = model
+	}
+	return switches
+}
+
+// answeredModel 返回主会话 assistant 消息的模型，其他消息返回空
+func answeredModel(msg *TranscriptMessage) string {
+	if msg.Type != "assistant" || msg.IsSidechain || msg.Message == nil {
+		return ""
+	}
+	// 请求失败时写入的 <synthetic> 消息不是模型的回答
+	if msg.Message.Model == "<synthetic>" {
+		return ""
+	}
+	return msg.Message.Model
+}
+
+// normalizeModelID 去掉代理或云厂商附加的部分，只保留模型本身，
+// 如 "us.anthropic.claude-sonnet-4-20250514-v1:0"、"claude-sonnet-4@20250514"、"claude-sonnet-4[1m]" 都视为 "claude-sonnet-4"
+func normalizeModelID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id = id[i+1:]
+	}
+	if i := strings.Index(id, "claude-"); i > 0 {
+		id = id[i:]
+	}
+	if i := strings.IndexAny(id, "[@"); i >= 0 {
+		id = id[:i]
+	}
+	if i := strings.Index(id, "-v"); i >= 0 && strings.Contains(id[i:], ":") {
+		id = id[:i]
+	}
+	id = strings.TrimSuffix(id, "-latest")
+	return modelDateSuffix.ReplaceAllString(id, "")
 }
 
 func simplifyModelName(name string) string {
@@ -0,0 +1,82 @@
+package segment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// Usage periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// DailyUsageSegment 显示今天所有会话的 token 与本地计算的费用
+type DailyUsageSegment struct {
+	Pricing map[string]config.ModelPricing
+}
+
+func (s *DailyUsageSegment) Collect(input *config.InputData) SegmentData {
+	return collectPeriodUsage(PeriodDay, s.Pricing)
+}
+
+// WeeklyUsageSegment 显示本周（周一起）所有会话的 token 与费用
+type WeeklyUsageSegment struct {
+	Pricing map[string]config.ModelPricing
+}
+
+func (s *WeeklyUsageSegment) Collect(input *config.InputData) SegmentData {
+	return collectPeriodUsage(PeriodWeek, s.Pricing)
+}
+
+// MonthlyUsageSegment 显示本月所有会话的 token 与费用
+type MonthlyUsageSegment struct {
+	Pricing map[string]config.ModelPricing
+}
+
+func (s *MonthlyUsageSegment) Collect(input *config.InputData) SegmentData {
+	return collectPeriodUsage(PeriodMonth, s.Pricing)
+}
+
+func collectPeriodUsage(period string, pricing map[string]config.ModelPricing) SegmentData {
+	start := PeriodStart(period, time.Now())
+
+	var cost float64
+	var tokens int
+	sessions := make(map[string]bool)
+	for _, entry := range LoadUsageEntries(start) {
+		cost += entry.Cost(pricing)
+		tokens += entry.Tokens()
+		sessions[entry.SessionID] = true
+	}
+
+	if tokens == 0 {
+		return SegmentData{}
+	}
+	return SegmentData{
+		Primary: fmt.Sprintf("$%.2f · %s tok", cost, formatTokenCount(tokens)),
+		Metadata: map[string]string{
+			"cost":     fmt.Sprintf("%.4f", cost),
+			"tokens":   fmt.Sprintf("%d", tokens),
+			"sessions": fmt.Sprintf("%d", len(sessions)),
+		},
+	}
+}
+
+// PeriodStart returns the start of the period containing t in local time; weeks start on Monday
+func PeriodStart(period string, t time.Time) time.Time {
+	t = t.Local()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	switch period {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	default:
+		return day
+	}
+}
@@ -0,0 +1,116 @@
+package segment
+
+import (
+	"os"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// defaultModelPricing 内置价格表（USD / 百万 token），key 为模型 ID 片段，匹配时取最长的片段
+var defaultModelPricing = map[string]config.ModelPricing{
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite5m: 6.25, CacheWrite1h: 10, CacheRead: 0.5},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.5},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.3},
+	"claude-haiku-4":    {Input: 1, Output: 5, CacheWrite5m: 1.25, CacheWrite1h: 2, CacheRead: 0.1},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.5},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.3},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.3},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheWrite5m: 1, CacheWrite1h: 1.6, CacheRead: 0.08},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheWrite5m: 0.3, CacheWrite1h: 0.5, CacheRead: 0.03},
+}
+
+// lookupModelPricing 按模型 ID 查找价格，配置中的覆盖项优先于内置价格表
+func lookupModelPricing(modelID string, overrides map[string]config.ModelPricing) (config.ModelPricing, bool) {
+	modelID = strings.ToLower(modelID)
+	if price, ok := matchModelPricing(modelID, overrides); ok {
+		return price, true
+	}
+	return matchModelPricing(modelID, defaultModelPricing)
+}
+
+// matchModelPricing 返回匹配最长片段的价格，避免 "claude-opus-4" 抢先匹配 "claude-opus-4-5"
+func matchModelPricing(modelID string, table map[string]config.ModelPricing) (config.ModelPricing, bool) {
+	var best string
+	for pattern := range table {
+		if strings.Contains(modelID, strings.ToLower(pattern)) && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return config.ModelPricing{}, false
+	}
+	return table[best], true
+}
+
+// usageCost 按价格表计算单条消息的费用，未拆分缓存层级时缓存写入按 5 分钟计价
+func usageCost(usage *UsageData, price config.ModelPricing) float64 {
+	write5m, write1h := usage.CacheCreationInputTokens, 0
+	if usage.CacheCreation != nil {
+		write5m = usage.CacheCreation.Ephemeral5mInputTokens
+		write1h = usage.CacheCreation.Ephemeral1hInputTokens
+	}
+
+	cost := float64(usage.InputTokens)*price.Input +
+		float64(usage.OutputTokens)*price.Output +
+		float64(write5m)*price.CacheWrite5m +
+		float64(write1h)*price.CacheWrite1h +
+		float64(usage.CacheReadInputTokens)*price.CacheRead
+	return cost / 1e6
+}
+
+// dedupKey 返回用于去重的 key，同一响应拆成多条记录或请求重试时 message.id 与 requestId 相同
+func (m *TranscriptMessage) dedupKey() string {
+	if m.Message == nil || m.Message.ID == "" {
+		return ""
+	}
+	return m.Message.ID + ":" + m.RequestID
+}
+
+// assistantUsages 返回 transcript 中去重后的 assistant 消息，同一 key 以最后一次记录为准
+func assistantUsages(lines []string) []*TranscriptMessage {
+	var messages []*TranscriptMessage
+	seen := make(map[string]int)
+
+	for _, line := range lines {
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.Type != "assistant" || msg.Message == nil || msg.Message.Usage == nil {
+			continue
+		}
+
+		key := msg.dedupKey()
+		if key == "" {
+			messages = append(messages, msg)
+			continue
+		}
+		if i, ok := seen[key]; ok {
+			messages[i] = msg
+			continue
+		}
+		seen[key] = len(messages)
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+// transcriptCost 计算 transcript 中所有 assistant 消息的本地费用
+func transcriptCost(path string, overrides map[string]config.ModelPricing) (float64, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	var total float64
+	priced := false
+	for _, msg := range assistantUsages(readAllLines(file)) {
+		price, ok := lookupModelPricing(msg.Message.Model, overrides)
+		if !ok {
+			continue
+		}
+		total += usageCost(msg.Message.Usage, price)
+		priced = true
+	}
+	return total, priced
+}
@@ -0,0 +1,90 @@
+package segment
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// SessionTitleSegment 显示会话标题：优先使用 summary 条目，否则取第一条用户提示词
+type SessionTitleSegment struct {
+	Options config.SessionTitleOptions
+}
+
+func (s *SessionTitleSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	title, source := sessionTitle(readTranscriptLines(input.TranscriptPath))
+	if title == "" {
+		return SegmentData{}
+	}
+
+	primary := title
+	if s.Options.MaxWidth > 0 {
+		primary = ansi.Truncate(title, s.Options.MaxWidth, "…")
+	}
+
+	return SegmentData{
+		Primary: primary,
+		Metadata: map[string]string{
+			"title":  title,
+			"source": source,
+		},
+	}
+}
+
+// sessionTitle 返回最后一条 summary 的文本，没有 summary 时返回第一条用户提示词
+func sessionTitle(lines []string) (string, string) {
+	prompt := ""
+	summary := ""
+	for _, line := range lines {
+		msg := parseTranscriptLine(line)
+		if msg == nil {
+			continue
+		}
+		switch {
+		case msg.Type == "summary" && msg.Summary != "":
+			summary = msg.Summary
+		case prompt == "" && msg.isUserPrompt():
+			prompt = msg.promptText()
+		}
+	}
+
+	if summary != "" {
+		return singleLine(summary), "summary"
+	}
+	if prompt != "" {
+		return singleLine(prompt), "prompt"
+	}
+	return "", ""
+}
+
+// promptText 返回用户提示词的文本，斜杠命令等以 XML 标签包裹的内容返回空
+func (m *TranscriptMessage) promptText() string {
+	content := m.Message.Content
+	var text string
+	if len(content) > 0 && content[0] == '"' {
+		json.Unmarshal(content, &text)
+	} else {
+		for _, block := range m.Message.blocks() {
+			if block.Type == "text" && block.Text != "" {
+				text = block.Text
+				break
+			}
+		}
+	}
+
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "<command-") || strings.HasPrefix(text, "<local-command-") {
+		return ""
+	}
+	return text
+}
+
+// singleLine 将多行文本折叠为单行
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,139 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// 会话状态存储：每次渲染都是独立进程，上一轮的指标需要落盘才能计算增量
+const (
+	sessionStateFile = "session-state.json"
+	sessionStateLock = "session-state.lock"
+	// sessionStateTTL 超过该时间未更新的会话会被清理
+	sessionStateTTL = 7 * 24 * time.Hour
+)
+
+// sessionState 单个会话的指标快照
+type sessionState struct {
+	UpdatedAt int64              `json:"updated_at"`
+	Turn      string             `json:"turn"`       // 当前轮次，取最后一条用户提示词的 uuid
+	TurnStart map[string]float64 `json:"turn_start"` // 本轮开始（即上一轮结束）时的指标
+	Last      map[string]float64 `json:"last"`       // 最近一次渲染时的指标
+}
+
+// sessionDelta 记录 session 在当前轮次的指标值，返回相对上一轮结束时的增量
+// 首次出现的会话或指标没有基准，返回 false
+func sessionDelta(sessionID, turn, name string, value float64) (float64, bool) {
+	if sessionID == "" {
+		return 0, false
+	}
+
+	var delta float64
+	var ok bool
+	err := updateSessionStates(func(states map[string]*sessionState) {
+		state := states[sessionID]
+		if state == nil {
+			state = &sessionState{Turn: turn, Last: make(map[string]float64)}
+			states[sessionID] = state
+		}
+
+		// 进入新一轮时，以上一次渲染的指标作为本轮基准
+		if state.Turn != turn {
+			state.Turn = turn
+			state.TurnStart = make(map[string]float64, len(state.Last))
+			for k, v := range state.Last {
+				state.TurnStart[k] = v
+			}
+		}
+
+		state.Last[name] = value
+		state.UpdatedAt = time.Now().Unix()
+
+		if start, exists := state.TurnStart[name]; exists {
+			delta, ok = value-start, true
+		}
+	})
+	if err != nil {
+		return 0, false
+	}
+	return delta, ok
+}
+
+// updateSessionStates 在文件锁保护下读取、修改并写回会话状态，同时清理过期会话
+// Claude Code 可能并发渲染多个状态栏，读改写必须串行
+func updateSessionStates(update func(map[string]*sessionState)) error {
+	dir := config.CacheDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, sessionStateLock), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	states := make(map[string]*sessionState)
+	if data, err := os.ReadFile(filepath.Join(dir, sessionStateFile)); err == nil {
+		json.Unmarshal(data, &states)
+	}
+
+	update(states)
+
+	cutoff := time.Now().Add(-sessionStateTTL).Unix()
+	for id, state := range states {
+		if state == nil || state.UpdatedAt < cutoff {
+			delete(states, id)
+		}
+	}
+
+	return writeCache(sessionStateFile, states)
+}
+
+// readTranscriptLines 读取 transcript 的所有行，失败时返回 nil
+func readTranscriptLines(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	return readAllLines(file)
+}
+
+// currentTurn 返回 transcript 中最后一条用户提示词的 uuid，用于识别轮次
+func currentTurn(lines []string) string {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if msg := parseTranscriptLine(lines[i]); msg != nil && msg.isUserPrompt() {
+			if msg.UUID != "" {
+				return msg.UUID
+			}
+			return msg.Timestamp
+		}
+	}
+	return ""
+}
+
+// formatTokenDelta 格式化 token 增量，如 "+2.3K tok"
+func formatTokenDelta(delta int) string {
+	if delta < 0 {
+		return "-" + formatTokenCount(-delta) + " tok"
+	}
+	return "+" + formatTokenCount(delta) + " tok"
+}
+
+// formatCostDelta 格式化费用增量，如 "+$0.04"
+func formatCostDelta(delta float64) string {
+	if delta < 0 {
+		return fmt.Sprintf("-$%.2f", -delta)
+	}
+	return fmt.Sprintf("+$%.2f", delta)
+}
@@ -0,0 +1,115 @@
+package segment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// 启动子代理的工具名，新版本的 Claude Code 将 Task 更名为 Agent
+var subagentTools = map[string]bool{
+	"Task":  true,
+	"Agent": true,
+}
+
+// SubagentsSegment 显示 Task 子代理的运行/完成数量以及消耗的 token 与费用
+type SubagentsSegment struct {
+	Pricing map[string]config.ModelPricing
+}
+
+// subagentActivity transcript 中解析出的子代理统计
+type subagentActivity struct {
+	running  int
+	finished int
+	tokens   int
+	cost     float64
+}
+
+func (s *SubagentsSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	activity := parseSubagentActivity(readTranscriptLines(input.TranscriptPath), s.Pricing)
+	if activity.running+activity.finished == 0 && activity.tokens == 0 {
+		return SegmentData{}
+	}
+
+	var parts []string
+	if activity.running > 0 {
+		parts = append(parts, fmt.Sprintf("%d running", activity.running))
+	}
+	if activity.finished > 0 {
+		parts = append(parts, fmt.Sprintf("%d done", activity.finished))
+	}
+	if activity.tokens > 0 {
+		parts = append(parts, formatTokenCount(activity.tokens)+" tok")
+	}
+	if activity.cost >= 0.005 {
+		parts = append(parts, fmt.Sprintf("$%.2f", activity.cost))
+	}
+
+	return SegmentData{
+		Primary: strings.Join(parts, " · "),
+		Metadata: map[string]string{
+			"running":  fmt.Sprintf("%d", activity.running),
+			"finished": fmt.Sprintf("%d", activity.finished),
+			"tokens":   fmt.Sprintf("%d", activity.tokens),
+			"cost":     fmt.Sprintf("%.4f", activity.cost),
+		},
+	}
+}
+
+// parseSubagentActivity 以主会话中的 Task 调用及其结果统计子代理数量，
+// 以 sidechain 中的 assistant 消息统计子代理的用量
+func parseSubagentActivity(lines []string, overrides map[string]config.ModelPricing) subagentActivity {
+	var activity subagentActivity
+
+	launched := make(map[string]bool) // tool_use id -> 是否已返回结果
+	var order []string
+	var sidechain []string
+	for _, line := range lines {
+		if !strings.Contains(line, `"isSidechain":true`) && !strings.Contains(line, `"tool_use"`) && !strings.Contains(line, `"tool_result"`) {
+			continue
+		}
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.Message == nil {
+			continue
+		}
+		if msg.IsSidechain {
+			sidechain = append(sidechain, line)
+			continue
+		}
+
+		for _, block := range msg.Message.blocks() {
+			switch {
+			case block.Type == "tool_use" && subagentTools[block.Name]:
+				if _, seen := launched[block.ID]; !seen {
+					launched[block.ID] = false
+					order = append(order, block.ID)
+				}
+			case block.Type == "tool_result":
+				if _, ok := launched[block.ToolUseID]; ok {
+					launched[block.ToolUseID] = true
+				}
+			}
+		}
+	}
+
+	for _, id := range order {
+		if launched[id] {
+			activity.finished++
+		} else {
+			activity.running++
+		}
+	}
+
+	for _, msg := range assistantUsages(sidechain) {
+		activity.tokens += msg.Message.Usage.displayTokens()
+		if price, ok := lookupModelPricing(msg.Message.Model, overrides); ok {
+			activity.cost += usageCost(msg.Message.Usage, price)
+		}
+	}
+
+	return activity
+}
@@ -0,0 +1,61 @@
+package segment
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// ThinkingSegment 显示最近一轮是否使用了 extended thinking 及思考内容的大致 token 数
+type ThinkingSegment struct{}
+
+func (s *ThinkingSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	lines := readTranscriptLines(input.TranscriptPath)
+
+	// 最近一轮从最后一条用户提示词开始
+	turnStart := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		if msg := parseTranscriptLine(lines[i]); msg != nil && msg.isUserPrompt() {
+			turnStart = i
+			break
+		}
+	}
+
+	blocks, chars := 0, 0
+	for _, line := range lines[turnStart:] {
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.Type != "assistant" || msg.IsSidechain || msg.Message == nil {
+			continue
+		}
+		for _, block := range msg.Message.blocks() {
+			// redacted_thinking 的内容已加密，只计数不估算大小
+			if block.Type == "thinking" || block.Type == "redacted_thinking" {
+				blocks++
+				chars += utf8.RuneCountInString(block.Thinking)
+			}
+		}
+	}
+	// 未开启 thinking 时隐藏
+	if blocks == 0 {
+		return SegmentData{}
+	}
+
+	metadata := map[string]string{
+		"blocks": fmt.Sprintf("%d", blocks),
+	}
+	if chars == 0 {
+		return SegmentData{Primary: "on", Metadata: metadata}
+	}
+
+	// 粗略按每 4 个字符 1 个 token 估算
+	tokens := (chars + 3) / 4
+	metadata["tokens"] = fmt.Sprintf("%d", tokens)
+	return SegmentData{
+		Primary:  "~" + formatTokenCount(tokens) + " tok",
+		Metadata: metadata,
+	}
+}
@@ -0,0 +1,112 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Todo 状态
+const (
+	todoPending    = "pending"
+	todoInProgress = "in_progress"
+	todoCompleted  = "completed"
+)
+
+// todoItem TodoWrite 工具参数中的单个任务
+type todoItem struct {
+	Content    string `json:"content"`
+	Status     string `json:"status"`
+	ActiveForm string `json:"activeForm"`
+}
+
+// TodosSegment 显示最近一次 TodoWrite 的任务进度与进行中的任务
+type TodosSegment struct {
+	Options config.TodosOptions
+}
+
+func (s *TodosSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	todos, ok := latestTodos(readTranscriptLines(input.TranscriptPath))
+	if !ok || len(todos) == 0 {
+		return SegmentData{}
+	}
+
+	completed := 0
+	var active *todoItem
+	for i := range todos {
+		switch todos[i].Status {
+		case todoCompleted:
+			completed++
+		case todoInProgress:
+			if active == nil {
+				active = &todos[i]
+			}
+		}
+	}
+	// 全部完成后隐藏
+	if completed == len(todos) {
+		return SegmentData{}
+	}
+
+	primary := fmt.Sprintf("%d/%d ✓", completed, len(todos))
+	metadata := map[string]string{
+		"completed": fmt.Sprintf("%d", completed),
+		"total":     fmt.Sprintf("%d", len(todos)),
+		"percent":   formatPercentValue(float64(completed) / float64(len(todos)) * 100),
+		// 完成度越高越好，进度条按剩余部分着色
+		"percent_scale": "higher_is_better",
+	}
+
+	if active != nil {
+		text := active.ActiveForm
+		if text == "" {
+			text = active.Content
+		}
+		text = singleLine(text)
+		metadata["active"] = text
+		if s.Options.MaxWidth > 0 && text != "" {
+			primary += " · " + ansi.Truncate(text, s.Options.MaxWidth, "…")
+		}
+	}
+
+	return SegmentData{
+		Primary:  primary,
+		Metadata: metadata,
+	}
+}
+
+// latestTodos 从后向前查找最后一次 TodoWrite 调用的任务列表
+func latestTodos(lines []string) ([]todoItem, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		// 先做廉价的字符串过滤，避免逐行完整解析
+		if !strings.Contains(lines[i], `"TodoWrite"`) {
+			continue
+		}
+		msg := parseTranscriptLine(lines[i])
+		// 子代理维护自己的待办列表，只看主会话
+		if msg == nil || msg.Type != "assistant" || msg.IsSidechain || msg.Message == nil {
+			continue
+		}
+
+		blocks := msg.Message.blocks()
+		for j := len(blocks) - 1; j >= 0; j-- {
+			if blocks[j].Type != "tool_use" || blocks[j].Name != "TodoWrite" {
+				continue
+			}
+			var params struct {
+				Todos []todoItem `json:"todos"`
+			}
+			if err := json.Unmarshal(blocks[j].Input, &params); err != nil {
+				continue
+			}
+			return params.Todos, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,201 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ToolsSegment 显示最近一次工具调用及其目标、各工具调用次数与失败次数
+type ToolsSegment struct {
+	Options config.ToolsOptions
+}
+
+// toolActivity transcript 中解析出的工具调用统计
+type toolActivity struct {
+	last   string // 最近一次调用的工具名
+	target string // 最近一次调用的目标，如文件名或命令
+	counts map[string]int
+	total  int
+	errors int
+}
+
+func (s *ToolsSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	activity := parseToolActivity(readTranscriptLines(input.TranscriptPath), s.Options.Tools)
+	if activity.total == 0 {
+		return SegmentData{}
+	}
+
+	primary := s.abbreviate(activity.last)
+	if activity.target != "" {
+		target := activity.target
+		if s.Options.TargetWidth > 0 {
+			target = ansi.Truncate(target, s.Options.TargetWidth, "…")
+		}
+		primary += " " + target
+	}
+
+	metadata := map[string]string{
+		"last_tool": activity.last,
+		"target":    activity.target,
+		"total":     fmt.Sprintf("%d", activity.total),
+		"errors":    fmt.Sprintf("%d", activity.errors),
+	}
+	for name, count := range activity.counts {
+		metadata["count_"+name] = fmt.Sprintf("%d", count)
+	}
+
+	// 按调用次数降序，次数相同时按工具名排序
+	if s.Options.MaxCounts > 0 {
+		names := sortedKeys(activity.counts)
+		sort.SliceStable(names, func(i, j int) bool {
+			return activity.counts[names[i]] > activity.counts[names[j]]
+		})
+		if len(names) > s.Options.MaxCounts {
+			names = names[:s.Options.MaxCounts]
+		}
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = fmt.Sprintf("%s×%d", s.abbreviate(name), activity.counts[name])
+		}
+		primary += " · " + strings.Join(parts, " ")
+	}
+
+	if activity.errors > 0 {
+		primary += fmt.Sprintf(" · ✗%d", activity.errors)
+	}
+
+	return SegmentData{
+		Primary:  primary,
+		Metadata: metadata,
+	}
+}
+
+// abbreviate 返回工具名的缩写，未配置时返回原名
+func (s *ToolsSegment) abbreviate(name string) string {
+	if abbr, ok := s.Options.Abbreviations[name]; ok {
+		return abbr
+	}
+	return name
+}
+
+// parseToolActivity 统计 tool_use 与 tool_result，tools 非空时只统计其中的工具
+func parseToolActivity(lines []string, tools []string) toolActivity {
+	activity := toolActivity{counts: make(map[string]int)}
+
+	allowed := make(map[string]bool, len(tools))
+	for _, name := range tools {
+		allowed[name] = true
+	}
+
+	// 流式输出时同一条消息可能被写入多次，按 tool_use id 去重
+	names := make(map[string]string)
+	failed := make(map[string]bool)
+	for _, line := range lines {
+		if !strings.Contains(line, `"tool_use"`) && !strings.Contains(line, `"tool_result"`) {
+			continue
+		}
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.Message == nil {
+			continue
+		}
+
+		for _, block := range msg.Message.blocks() {
+			switch {
+			case block.Type == "tool_use" && msg.Type == "assistant":
+				if len(allowed) > 0 && !allowed[block.Name] {
+					continue
+				}
+				if block.ID != "" {
+					if _, seen := names[block.ID]; seen {
+						continue
+					}
+					names[block.ID] = block.Name
+				}
+				activity.counts[block.Name]++
+				activity.total++
+				activity.last = block.Name
+				activity.target = toolTarget(block.Input)
+			case block.Type == "tool_result" && block.IsError:
+				// 只记录了参与统计的工具，过滤时据此排除其他工具的失败
+				if _, ok := names[block.ToolUseID]; !ok && len(allowed) > 0 {
+					continue
+				}
+				if block.ToolUseID != "" {
+					if failed[block.ToolUseID] {
+						continue
+					}
+					failed[block.ToolUseID] = true
+				}
+				activity.errors++
+			}
+		}
+	}
+	return activity
+}
+
+// maxFreeTextTarget 搜索模式、查询与描述等自由文本目标的最大宽度（单元格），
+// 与命令只取前两个词、路径只取文件名一样，target_width 为 0 时也不会占满状态栏
+const maxFreeTextTarget = 40
+
+// toolTarget 从工具参数中提取简短的目标描述
+func toolTarget(input json.RawMessage) string {
+	var params struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+		Path         string `json:"path"`
+		Command      string `json:"command"`
+		Pattern      string `json:"pattern"`
+		URL          string `json:"url"`
+		Query        string `json:"query"`
+		SubagentType string `json:"subagent_type"`
+		Description  string `json:"description"`
+	}
+	if err := json.Unmarshal(input, &params); err != nil {
+		return ""
+	}
+
+	switch {
+	case params.FilePath != "":
+		return filepath.Base(params.FilePath)
+	case params.NotebookPath != "":
+		return filepath.Base(params.NotebookPath)
+	case params.Command != "":
+		// 命令只保留前两个词，如 "go test"
+		fields := strings.Fields(params.Command)
+		if len(fields) > 2 {
+			fields = fields[:2]
+		}
+		return strings.Join(fields, " ")
+	case params.Pattern != "":
+		return freeTextTarget(params.Pattern)
+	case params.URL != "":
+		if u, err := url.Parse(params.URL); err == nil && u.Host != "" {
+			return u.Host
+		}
+		return params.URL
+	case params.Query != "":
+		return freeTextTarget(params.Query)
+	case params.SubagentType != "":
+		return params.SubagentType
+	case params.Description != "":
+		return freeTextTarget(params.Description)
+	case params.Path != "":
+		return filepath.Base(params.Path)
+	}
+	return ""
+}
+
+// freeTextTarget 将可能多行的自由文本压成一行并截断
+func freeTextTarget(text string) string {
+	return ansi.Truncate(singleLine(text), maxFreeTextTarget, "…")
+}
@@ -0,0 +1,93 @@
+package segment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/WAY29/cchline/config"
+)
+
+// TurnsSegment 显示会话的轮数、消息数与压缩次数
+type TurnsSegment struct{}
+
+// turnCounts transcript 中的对话统计，不含 meta 消息与子代理
+type turnCounts struct {
+	prompts     int // 用户提示词，即轮数
+	replies     int // assistant 回复，按 message.id 去重
+	toolResults int // 工具往返次数
+	compactions int
+}
+
+func (s *TurnsSegment) Collect(input *config.InputData) SegmentData {
+	if input.TranscriptPath == "" {
+		return SegmentData{}
+	}
+	counts := countTurns(readTranscriptLines(input.TranscriptPath))
+	if counts.prompts == 0 {
+		return SegmentData{}
+	}
+
+	messages := counts.prompts + counts.replies + counts.toolResults
+	parts := []string{
+		fmt.Sprintf("T%d", counts.prompts),
+		fmt.Sprintf("%d msgs", messages),
+	}
+	if counts.compactions > 0 {
+		parts = append(parts, fmt.Sprintf("%d×compact", counts.compactions))
+	}
+
+	return SegmentData{
+		Primary: strings.Join(parts, " · "),
+		Metadata: map[string]string{
+			"turns":        fmt.Sprintf("%d", counts.prompts),
+			"messages":     fmt.Sprintf("%d", messages),
+			"replies":      fmt.Sprintf("%d", counts.replies),
+			"tool_results": fmt.Sprintf("%d", counts.toolResults),
+			"compactions":  fmt.Sprintf("%d", counts.compactions),
+		},
+	}
+}
+
+// countTurns 统计提示词、回复、工具往返与压缩次数
+func countTurns(lines []string) turnCounts {
+	var counts turnCounts
+	replies := make(map[string]bool)
+	afterBoundary := false
+	for _, line := range lines {
+		msg := parseTranscriptLine(line)
+		if msg == nil || msg.IsSidechain {
+			continue
+		}
+
+		switch {
+		case msg.isCompactBoundary():
+			counts.compactions++
+			afterBoundary = true
+		case msg.IsCompactSummary:
+			// 旧版本只写入压缩摘要消息而没有 compact_boundary
+			if !afterBoundary {
+				counts.compactions++
+			}
+			afterBoundary = false
+		case msg.IsMeta || msg.Message == nil:
+		case msg.isUserPrompt():
+			counts.prompts++
+		case msg.Type == "user":
+			for _, block := range msg.Message.blocks() {
+				if block.Type == "tool_result" {
+					counts.toolResults++
+				}
+			}
+		case msg.Type == "assistant":
+			// 流式输出时同一条回复的每个内容块各占一行
+			if msg.Message.ID != "" {
+				if replies[msg.Message.ID] {
+					continue
+				}
+				replies[msg.Message.ID] = true
+			}
+			counts.replies++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// usageLine builds a timestamped sonnet response costing $1.50
+func usageLine(id string, at time.Time) string {
+	return fmt.Sprintf(`{"type":"assistant","timestamp":%q,"sessionId":"s-%s","cwd":"/work/app","requestId":"req_%s","message":{"id":%q,"model":"claude-sonnet-4-20250514","usage":{"input_tokens":0,"output_tokens":100000,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}`,
+		at.UTC().Format(time.RFC3339Nano), id, id, id)
+}
+
+// setupProjects points HOME at a temp dir with an empty Claude projects directory
+func setupProjects(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	dir := filepath.Join(home, ".claude", "projects")
+	if err := os.MkdirAll(filepath.Join(dir, "-work-app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func appendLines(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPeriodUsageSegments(t *testing.T) {
+	dir := setupProjects(t)
+	now := time.Now()
+	first := filepath.Join(dir, "-work-app", "first.jsonl")
+	resumed := filepath.Join(dir, "-work-app", "resumed.jsonl")
+
+	// msg_1 is written twice by one session and copied into the resumed one.
+	appendLines(t, first, userLine+"\n"+usageLine("1", now)+"\n"+usageLine("1", now)+"\n"+usageLine("2", now)+"\n")
+	appendLines(t, resumed, usageLine("1", now)+"\n"+usageLine("3", now)+"\n"+usageLine("old", now.AddDate(0, 0, -40))+"\n")
+
+	collectors := map[string]segment.Segment{
+		"daily":   &segment.DailyUsageSegment{},
+		"weekly":  &segment.WeeklyUsageSegment{},
+		"monthly": &segment.MonthlyUsageSegment{},
+	}
+	check := func(want string) {
+		t.Helper()
+		for name, seg := range collectors {
+			if got := seg.Collect(&config.InputData{}).Primary; got != want {
+				t.Errorf("%s: got %q, want %q", name, got, want)
+			}
+		}
+	}
+	check("$4.50 · 300.0K tok")
+
+	shards, _ := filepath.Glob(filepath.Join(config.CacheDir(), "usage-*.json"))
+	if len(shards) != 2 {
+		t.Errorf("expected one usage index shard per transcript, got %v", shards)
+	}
+
+	// Appended lines are picked up; a half-written line waits until it is complete.
+	appendLines(t, resumed, usageLine("4", now)+"\n")
+	check("$6.00 · 400.0K tok")
+	line := usageLine("5", now)
+	appendLines(t, first, line[:40])
+	check("$6.00 · 400.0K tok")
+	appendLines(t, first, line[40:]+"\n")
+	check("$7.50 · 500.0K tok")
+
+	data := (&segment.DailyUsageSegment{}).Collect(&config.InputData{})
+	if data.Metadata["sessions"] != "5" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestUsageIndexRewritesOnlyChangedShards(t *testing.T) {
+	dir := setupProjects(t)
+	now := time.Now()
+	first := filepath.Join(dir, "-work-app", "first.jsonl")
+	second := filepath.Join(dir, "-work-app", "second.jsonl")
+	appendLines(t, first, usageLine("1", now)+"\n")
+	appendLines(t, second, usageLine("2", now)+"\n")
+	segment.LoadUsageEntries(time.Time{})
+
+	// Age every shard, then append to one transcript only.
+	shards, _ := filepath.Glob(filepath.Join(config.CacheDir(), "usage-*.json"))
+	old := now.Add(-time.Hour)
+	for _, shard := range shards {
+		os.Chtimes(shard, old, old)
+	}
+	appendLines(t, second, usageLine("3", now)+"\n")
+	if got := len(segment.LoadUsageEntries(time.Time{})); got != 3 {
+		t.Fatalf("got %d entries, want 3", got)
+	}
+
+	rewritten := 0
+	for _, shard := range shards {
+		if info, err := os.Stat(shard); err == nil && info.ModTime().After(old) {
+			rewritten++
+		}
+	}
+	if rewritten != 1 {
+		t.Errorf("expected only the changed shard to be rewritten, got %d", rewritten)
+	}
+
+	// Shards of deleted transcripts and the legacy single-file index are pruned.
+	os.WriteFile(filepath.Join(config.CacheDir(), "usage-index.json"), []byte("{}"), 0644)
+	os.Remove(first)
+	appendLines(t, second, usageLine("4", now)+"\n")
+	segment.LoadUsageEntries(time.Time{})
+	if shards, _ := filepath.Glob(filepath.Join(config.CacheDir(), "usage-*.json")); len(shards) != 1 {
+		t.Errorf("expected stale shards to be removed, got %v", shards)
+	}
+}
+
+func TestUsageEntriesSkipUntouchedTranscripts(t *testing.T) {
+	dir := setupProjects(t)
+	now := time.Now()
+	stale := filepath.Join(dir, "-work-app", "stale.jsonl")
+	appendLines(t, stale, usageLine("1", now)+"\n")
+	// A transcript last written before the period cannot hold entries inside it.
+	old := now.AddDate(0, -2, 0)
+	os.Chtimes(stale, old, old)
+
+	if got := len(segment.LoadUsageEntries(now.Add(-time.Hour))); got != 0 {
+		t.Errorf("expected the untouched transcript to be skipped, got %d entries", got)
+	}
+	if got := len(segment.LoadUsageEntries(time.Time{})); got != 1 {
+		t.Errorf("the full history should still include it, got %d entries", got)
+	}
+}
+
+func TestPeriodUsagePricingOverride(t *testing.T) {
+	dir := setupProjects(t)
+	appendLines(t, filepath.Join(dir, "-work-app", "s.jsonl"), usageLine("1", time.Now())+"\n")
+
+	seg := &segment.DailyUsageSegment{Pricing: map[string]config.ModelPricing{"claude-sonnet-4": {Output: 30}}}
+	if got := seg.Collect(&config.InputData{}).Primary; got != "$3.00 · 100.0K tok" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPeriodUsageNoProjects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	if got := (&segment.DailyUsageSegment{}).Collect(&config.InputData{}).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
+
+func TestBlockSegment(t *testing.T) {
+	dir := setupProjects(t)
+	now := time.Now()
+	first := now.Add(-100 * time.Minute)
+	appendLines(t, filepath.Join(dir, "-work-app", "s.jsonl"),
+		usageLine("old", now.Add(-8*time.Hour))+"\n"+usageLine("1", first)+"\n"+usageLine("2", now.Add(-40*time.Minute))+"\n")
+
+	data := (&segment.BlockSegment{}).Collect(&config.InputData{})
+	if !strings.HasPrefix(data.Primary, "$3.00 · 200.0K tok · ") {
+		t.Errorf("got %q", data.Primary)
+	}
+
+	start := first.Truncate(time.Hour)
+	if data.Metadata["start"] != start.Format(time.RFC3339) {
+		t.Errorf("block should start at %v, got %v", start, data.Metadata["start"])
+	}
+
+	// $3 over the 100 minutes since the first message, extrapolated to the block end.
+	remaining := start.Add(5 * time.Hour).Sub(now).Minutes()
+	want := 3 + 3/100.0*remaining
+	if got, _ := strconv.ParseFloat(data.Metadata["projected"], 64); math.Abs(got-want) > 0.05 {
+		t.Errorf("projected %v, want about %v", got, want)
+	}
+}
+
+func TestBlockSegmentExpired(t *testing.T) {
+	dir := setupProjects(t)
+	appendLines(t, filepath.Join(dir, "-work-app", "s.jsonl"), usageLine("1", time.Now().Add(-6*time.Hour))+"\n")
+	if got := (&segment.BlockSegment{}).Collect(&config.InputData{}).Primary; got != "" {
+		t.Errorf("no active block expected after a 5h gap, got %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// apiRetryLine builds a system api_error entry written while retrying
+func apiRetryLine(status int) string {
+	return fmt.Sprintf(`{"type":"system","subtype":"api_error","level":"error","error":{"status":%d},"retryInMs":1000,"retryAttempt":1}`, status)
+}
+
+// apiErrorMessageLine builds the synthetic assistant message written when retries are exhausted
+func apiErrorMessageLine(text string) string {
+	return fmt.Sprintf(`{"type":"assistant","isApiErrorMessage":true,"message":{"model":"<synthetic>","role":"assistant","content":[{"type":"text","text":%q}]}}`, text)
+}
+
+func collectAPIErrors(path string, quiet int) segment.SegmentData {
+	return (&segment.APIErrorsSegment{Options: config.APIErrorsOptions{QuietMinutes: quiet}}).Collect(&config.InputData{
+		TranscriptPath: path,
+	})
+}
+
+func TestAPIErrorsSegment(t *testing.T) {
+	now := time.Now()
+	path := writeTranscript(t,
+		userLine,
+		timedLine(apiRetryLine(429), now.Add(-5*time.Minute)),
+		timedLine(apiRetryLine(529), now.Add(-4*time.Minute)),
+		timedLine(apiErrorMessageLine(`API Error: 429 {"type":"error","error":{"type":"rate_limit_error"}}`), now.Add(-3*time.Minute-10*time.Second)),
+	)
+
+	data := collectAPIErrors(path, 10)
+	if want := "2×429 1×529 · 3m ago"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+	if data.Metadata["count"] != "3" || data.Metadata["last_status"] != "429" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestAPIErrorsSegmentCountsEachRequestOnce(t *testing.T) {
+	now := time.Now()
+	withParent := func(line, parent string) string {
+		return strings.Replace(line, "{", fmt.Sprintf(`{"parentUuid":%q,`, parent), 1)
+	}
+	path := writeTranscript(t,
+		userLine,
+		// Three retries of the same request followed by the terminal error message.
+		timedLine(withParent(apiRetryLine(529), "u1"), now.Add(-5*time.Minute)),
+		timedLine(withParent(apiRetryLine(529), "u1"), now.Add(-5*time.Minute)),
+		timedLine(withParent(apiRetryLine(429), "u1"), now.Add(-4*time.Minute)),
+		timedLine(withParent(apiErrorMessageLine("API Error: 429 rate limited"), "u1"), now.Add(-3*time.Minute-10*time.Second)),
+		// A separate request later in the session.
+		timedLine(withParent(apiRetryLine(529), "u2"), now.Add(-2*time.Minute-10*time.Second)),
+	)
+
+	data := collectAPIErrors(path, 10)
+	if want := "1×429 1×529 · 2m ago"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+	if data.Metadata["count"] != "2" || data.Metadata["last_status"] != "529" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestAPIErrorsSegmentStatusFromErrorPrefix(t *testing.T) {
+	path := writeTranscript(t,
+		timedLine(apiErrorMessageLine("API Error: Request timed out after 500 seconds"), time.Now()),
+	)
+	if got := collectAPIErrors(path, 10).Primary; got != "1×err · just now" {
+		t.Errorf("numbers outside the status prefix should be ignored, got %q", got)
+	}
+}
+
+func TestAPIErrorsSegmentFadesAfterQuietPeriod(t *testing.T) {
+	path := writeTranscript(t,
+		timedLine(apiErrorMessageLine("API Error: Repeated 529 Overloaded errors"), time.Now().Add(-20*time.Minute)),
+	)
+	if got := collectAPIErrors(path, 10).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+	if got := collectAPIErrors(path, 30).Primary; got != "1×529 · 20m ago" {
+		t.Errorf("got %q with longer quiet period", got)
+	}
+}
+
+func TestAPIErrorsSegmentNoErrors(t *testing.T) {
+	if got := collectAPIErrors(writeTranscript(t, userLine, assistantLine(1, 2, 0, 0)), 10).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
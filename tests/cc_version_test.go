@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+func collectCCVersion(version, minVersion string) segment.SegmentData {
+	return (&segment.CCVersionSegment{Options: config.CCVersionOptions{MinVersion: minVersion}}).Collect(&config.InputData{
+		Version: version,
+	})
+}
+
+func TestCCVersionSegment(t *testing.T) {
+	tests := []struct {
+		version, min, want, warning string
+	}{
+		{"1.0.90", "1.0.90", "1.0.90", ""},
+		{"2.0.14", "", "2.0.14", ""},
+		{"1.0.80", "1.0.90", "1.0.80 ⚠ < 1.0.90", "outdated"},
+		{"1.0.80", "v1.0.9", "1.0.80", ""},
+		{"3.0.0", "1.0.90", "3.0.0 ⚠ untested", "untested"},
+		{"1.0.80", "not-a-version", "1.0.80", ""},
+		{"nightly", "1.0.90", "nightly", ""},
+	}
+	for _, tc := range tests {
+		data := collectCCVersion(tc.version, tc.min)
+		if data.Primary != tc.want || data.Metadata["warning"] != tc.warning {
+			t.Errorf("version %q min %q: got %q (%v), want %q", tc.version, tc.min, data.Primary, data.Metadata, tc.want)
+		}
+	}
+}
+
+func TestCCVersionSegmentHiddenWithoutVersion(t *testing.T) {
+	if got := collectCCVersion("", "1.0.90").Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
+
+func TestParseSemVer(t *testing.T) {
+	cases := []struct {
+		in     string
+		wantOK bool
+		want   config.SemVer
+	}{
+		{"1.2.3", true, config.SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2.3", true, config.SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2.3-beta.1", true, config.SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2", false, config.SemVer{}},
+		{"dev", false, config.SemVer{}},
+		{"", false, config.SemVer{}},
+	}
+	for _, tc := range cases {
+		got, ok := config.ParseSemVer(tc.in)
+		if ok != tc.wantOK || (ok && got != tc.want) {
+			t.Errorf("ParseSemVer(%q) = %v, %v; want %v, %v", tc.in, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	a, _ := config.ParseSemVer("v1.2.3-beta.1")
+	b, _ := config.ParseSemVer("1.10.0")
+	if config.CompareSemVer(a, b) >= 0 || config.CompareSemVer(b, a) <= 0 || config.CompareSemVer(a, a) != 0 {
+		t.Errorf("unexpected ordering of %v and %v", a, b)
+	}
+	if config.CompareSemVer(config.SemVer{Major: 2}, config.SemVer{Major: 1, Minor: 9, Patch: 9}) <= 0 {
+		t.Errorf("expected 2.0.0 > 1.9.9")
+	}
+}
@@ -1,6 +1,8 @@
 package tests
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -55,6 +57,28 @@ func TestSegmentIDConstants(t *testing.T) {
 		config.SegmentCCHCost,
 		config.SegmentCCHRequests,
 		config.SegmentCCHLimits,
+		config.SegmentGitDiff,
+		config.SegmentContextEta,
+		config.SegmentCache,
+		config.SegmentDailyUsage,
+		config.SegmentWeeklyUsage,
+		config.SegmentMonthlyUsage,
+		config.SegmentBlock,
+		config.SegmentTodos,
+		config.SegmentTools,
+		config.SegmentSubagents,
+		config.SegmentSessionTitle,
+		config.SegmentFiles,
+		config.SegmentTurns,
+		config.SegmentThinking,
+		config.SegmentAPIErrors,
+		config.SegmentMCP,
+		config.SegmentCCVersion,
+		config.SegmentPythonEnv,
+		config.SegmentGoVersion,
+		config.SegmentNodeVersion,
+		config.SegmentKubeContext,
+		config.SegmentCloudProfile,
 	}
 
 	expectedValues := map[config.SegmentID]string{
@@ -72,6 +96,28 @@ func TestSegmentIDConstants(t *testing.T) {
 		config.SegmentCCHCost:       "cch_cost",
 		config.SegmentCCHRequests:   "cch_requests",
 		config.SegmentCCHLimits:     "cch_limits",
+		config.SegmentGitDiff:       "git_diff",
+		config.SegmentContextEta:    "context_eta",
+		config.SegmentCache:         "cache",
+		config.SegmentDailyUsage:    "daily_usage",
+		config.SegmentWeeklyUsage:   "weekly_usage",
+		config.SegmentMonthlyUsage:  "monthly_usage",
+		config.SegmentBlock:         "block",
+		config.SegmentTodos:         "todos",
+		config.SegmentTools:         "tools",
+		config.SegmentSubagents:     "subagents",
+		config.SegmentSessionTitle:  "session_title",
+		config.SegmentFiles:         "files",
+		config.SegmentTurns:         "turns",
+		config.SegmentThinking:      "thinking",
+		config.SegmentAPIErrors:     "api_errors",
+		config.SegmentMCP:           "mcp",
+		config.SegmentCCVersion:     "cc_version",
+		config.SegmentPythonEnv:     "python_env",
+		config.SegmentGoVersion:     "go_version",
+		config.SegmentNodeVersion:   "node_version",
+		config.SegmentKubeContext:   "kube_context",
+		config.SegmentCloudProfile:  "cloud_profile",
 	}
 
 	for _, segment := range expectedSegments {
@@ -333,6 +379,28 @@ func TestGetSegmentThemeDifferentIcons(t *testing.T) {
 		config.SegmentCCHCost,
 		config.SegmentCCHRequests,
 		config.SegmentCCHLimits,
+		config.SegmentGitDiff,
+		config.SegmentContextEta,
+		config.SegmentCache,
+		config.SegmentDailyUsage,
+		config.SegmentWeeklyUsage,
+		config.SegmentMonthlyUsage,
+		config.SegmentBlock,
+		config.SegmentTodos,
+		config.SegmentTools,
+		config.SegmentSubagents,
+		config.SegmentSessionTitle,
+		config.SegmentFiles,
+		config.SegmentTurns,
+		config.SegmentThinking,
+		config.SegmentAPIErrors,
+		config.SegmentMCP,
+		config.SegmentCCVersion,
+		config.SegmentPythonEnv,
+		config.SegmentGoVersion,
+		config.SegmentNodeVersion,
+		config.SegmentKubeContext,
+		config.SegmentCloudProfile,
 	}
 
 	for _, segment := range segments {
@@ -364,6 +432,28 @@ func TestGetSegmentThemeSameColors(t *testing.T) {
 		config.SegmentCCHCost,
 		config.SegmentCCHRequests,
 		config.SegmentCCHLimits,
+		config.SegmentGitDiff,
+		config.SegmentContextEta,
+		config.SegmentCache,
+		config.SegmentDailyUsage,
+		config.SegmentWeeklyUsage,
+		config.SegmentMonthlyUsage,
+		config.SegmentBlock,
+		config.SegmentTodos,
+		config.SegmentTools,
+		config.SegmentSubagents,
+		config.SegmentSessionTitle,
+		config.SegmentFiles,
+		config.SegmentTurns,
+		config.SegmentThinking,
+		config.SegmentAPIErrors,
+		config.SegmentMCP,
+		config.SegmentCCVersion,
+		config.SegmentPythonEnv,
+		config.SegmentGoVersion,
+		config.SegmentNodeVersion,
+		config.SegmentKubeContext,
+		config.SegmentCloudProfile,
 	}
 
 	for _, segment := range segments {
@@ -477,3 +567,175 @@ func TestSegmentThemeStructure(t *testing.T) {
 		t.Errorf("expected non-empty Icon for valid segment")
 	}
 }
+
+// writeTestConfig writes config.toml under a temporary HOME
+func writeTestConfig(t *testing.T, content string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".claude", "cchline")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLoadConfigGitOptions verifies [git] options and their defaults
+func TestLoadConfigGitOptions(t *testing.T) {
+	writeTestConfig(t, "theme = \"default\"\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Git != config.DefaultGitOptions() {
+		t.Errorf("expected default git options, got %+v", cfg.Git)
+	}
+
+	writeTestConfig(t, "[git]\nshow_worktree = false\nscan_child_repos = true\nstatus_cache_seconds = 30\n")
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.GitOptions{ShowWorktree: false, ShowSubmodule: true, ScanChildRepos: true, StatusCacheSeconds: 30}
+	if cfg.Git != want {
+		t.Errorf("got %+v, want %+v", cfg.Git, want)
+	}
+}
+
+// TestLoadConfigModelOptions verifies [model] options
+func TestLoadConfigModelOptions(t *testing.T) {
+	writeTestConfig(t, "[model]\nshow_switches = true\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Model.ShowSwitches {
+		t.Errorf("expected show_switches to be loaded, got %+v", cfg.Model)
+	}
+}
+
+// TestLoadConfigContextWindowOptions verifies [context_window] options and their defaults
+func TestLoadConfigContextWindowOptions(t *testing.T) {
+	writeTestConfig(t, "[context_window]\ncompact_aware = true\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.ContextWindowOptions{CompactAware: true, AutoCompactThreshold: 80}
+	if cfg.ContextWindow != want {
+		t.Errorf("got %+v, want %+v", cfg.ContextWindow, want)
+	}
+}
+
+func TestLoadConfigProgressBarOptions(t *testing.T) {
+	writeTestConfig(t, "[progress_bar]\nsegments = [\"context_window\"]\nstyle = \"ascii\"\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bar := cfg.ProgressBar
+	if bar.Style != config.ProgressBarStyleASCII || bar.Width != 10 || bar.WarnThreshold != 60 || bar.CriticalThreshold != 85 {
+		t.Errorf("unexpected options %+v", bar)
+	}
+	if !bar.Enabled(config.SegmentContextWindow) || bar.Enabled(config.SegmentCCHCost) {
+		t.Errorf("unexpected enabled segments %v", bar.Segments)
+	}
+}
+
+func TestLoadConfigContextEtaOptions(t *testing.T) {
+	writeTestConfig(t, "[context_eta]\nwindow = 3\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ContextEta.Window != 3 {
+		t.Errorf("got %+v, want window 3", cfg.ContextEta)
+	}
+
+	writeTestConfig(t, "theme = \"default\"\n")
+	if cfg, _ = config.LoadConfig(); cfg.ContextEta != config.DefaultContextEtaOptions() {
+		t.Errorf("expected default options, got %+v", cfg.ContextEta)
+	}
+}
+
+func TestLoadConfigTodosOptions(t *testing.T) {
+	writeTestConfig(t, "[todos]\nmax_width = 12\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Todos.MaxWidth != 12 {
+		t.Errorf("got %+v, want max_width 12", cfg.Todos)
+	}
+
+	writeTestConfig(t, "theme = \"default\"\n")
+	if cfg, _ = config.LoadConfig(); cfg.Todos != config.DefaultTodosOptions() {
+		t.Errorf("expected default options, got %+v", cfg.Todos)
+	}
+}
+
+func TestLoadConfigToolsOptions(t *testing.T) {
+	writeTestConfig(t, "[tools]\ntools = [\"Bash\"]\nmax_counts = 0\n\n[tools.abbreviations]\nBash = \"$\"\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.ToolsOptions{
+		Tools:         []string{"Bash"},
+		Abbreviations: map[string]string{"Bash": "$"},
+		TargetWidth:   20,
+	}
+	if !reflect.DeepEqual(cfg.Tools, want) {
+		t.Errorf("got %+v, want %+v", cfg.Tools, want)
+	}
+
+	writeTestConfig(t, "theme = \"default\"\n")
+	if cfg, _ = config.LoadConfig(); !reflect.DeepEqual(cfg.Tools, config.DefaultToolsOptions()) {
+		t.Errorf("expected default options, got %+v", cfg.Tools)
+	}
+}
+
+func TestLoadConfigSessionTitleOptions(t *testing.T) {
+	writeTestConfig(t, "[session_title]\nmax_width = 0\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SessionTitle.MaxWidth != 0 {
+		t.Errorf("got %+v, want max_width 0", cfg.SessionTitle)
+	}
+
+	writeTestConfig(t, "theme = \"default\"\n")
+	if cfg, _ = config.LoadConfig(); cfg.SessionTitle != config.DefaultSessionTitleOptions() {
+		t.Errorf("expected default options, got %+v", cfg.SessionTitle)
+	}
+}
+
+func TestLoadConfigAPIErrorsOptions(t *testing.T) {
+	writeTestConfig(t, "[api_errors]\nquiet_minutes = 30\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIErrors.QuietMinutes != 30 {
+		t.Errorf("got %+v, want quiet_minutes 30", cfg.APIErrors)
+	}
+
+	writeTestConfig(t, "theme = \"default\"\n")
+	if cfg, _ = config.LoadConfig(); cfg.APIErrors != config.DefaultAPIErrorsOptions() {
+		t.Errorf("expected default options, got %+v", cfg.APIErrors)
+	}
+}
+
+func TestLoadConfigCCVersionOptions(t *testing.T) {
+	writeTestConfig(t, "[cc_version]\nmin_version = \"1.0.90\"\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CCVersion.MinVersion != "1.0.90" {
+		t.Errorf("got %+v, want min_version 1.0.90", cfg.CCVersion)
+	}
+}
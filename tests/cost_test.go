@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// pricedAssistantLine builds an assistant entry with a model, message ID and request ID
+func pricedAssistantLine(model, id, requestID, usage string) string {
+	return fmt.Sprintf(`{"type":"assistant","requestId":%q,"message":{"id":%q,"model":%q,"role":"assistant","usage":%s}}`,
+		requestID, id, model, usage)
+}
+
+func costTranscript(t *testing.T) string {
+	sonnet := `{"input_tokens":1000,"output_tokens":2000,"cache_creation_input_tokens":10000,"cache_read_input_tokens":100000,"cache_creation":{"ephemeral_5m_input_tokens":4000,"ephemeral_1h_input_tokens":6000}}`
+	opus := `{"input_tokens":0,"output_tokens":10000,"cache_creation_input_tokens":20000,"cache_read_input_tokens":0}`
+	return writeTranscript(t,
+		userLine,
+		// One response split across two entries (text + tool_use) is billed once.
+		pricedAssistantLine("claude-sonnet-4-5-20250929", "msg_1", "req_1", sonnet),
+		pricedAssistantLine("claude-sonnet-4-5-20250929", "msg_1", "req_1", sonnet),
+		toolResultLine,
+		pricedAssistantLine("claude-opus-4-5-20251101", "msg_2", "req_2", opus),
+		// Synthetic error messages have no price and are skipped.
+		pricedAssistantLine("<synthetic>", "msg_3", "", `{"input_tokens":0,"output_tokens":0}`),
+	)
+}
+
+func collectCost(path string, total float64, opts config.CostOptions) segment.SegmentData {
+	return (&segment.CostSegment{Options: opts}).Collect(&config.InputData{
+		TranscriptPath: path,
+		Cost:           config.CostInfo{TotalCostUSD: total},
+	})
+}
+
+func TestCostSegmentLocalPricing(t *testing.T) {
+	path := costTranscript(t)
+
+	// sonnet: 1K in, 2K out, 4K 5m write, 6K 1h write, 100K read = $0.114
+	// opus 4.5: 10K out, 20K 5m write = $0.375
+	data := collectCost(path, 0, config.CostOptions{})
+	if data.Primary != "$0.49" || data.Metadata["source"] != config.CostSourceLocal {
+		t.Errorf("got %q (%v), want $0.49 from local pricing", data.Primary, data.Metadata)
+	}
+
+	if got := collectCost(path, 5, config.CostOptions{}).Primary; got != "$5.00" {
+		t.Errorf("auto should prefer total_cost_usd, got %q", got)
+	}
+	if got := collectCost(path, 5, config.CostOptions{Source: config.CostSourceLocal}).Primary; got != "$0.49" {
+		t.Errorf("local source should ignore total_cost_usd, got %q", got)
+	}
+	if got := collectCost(path, 0, config.CostOptions{Source: config.CostSourceInput}).Primary; got != "" {
+		t.Errorf("input source should not compute locally, got %q", got)
+	}
+}
+
+func TestCostSegmentPricingOverride(t *testing.T) {
+	opts := config.CostOptions{Pricing: map[string]config.ModelPricing{
+		"claude-opus-4-5": {Output: 10},
+	}}
+	if got := collectCost(costTranscript(t), 0, opts).Primary; got != "$0.21" {
+		t.Errorf("got %q, want $0.21", got)
+	}
+}
+
+func TestLoadConfigCostOptions(t *testing.T) {
+	writeTestConfig(t, "[cost]\nsource = \"local\"\n\n[cost.pricing.\"my-proxy-model\"]\ninput = 1\noutput = 2\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Cost.Source != config.CostSourceLocal {
+		t.Errorf("got source %q", cfg.Cost.Source)
+	}
+	if price := cfg.Cost.Pricing["my-proxy-model"]; price.Input != 1 || price.Output != 2 {
+		t.Errorf("unexpected pricing %+v", cfg.Cost.Pricing)
+	}
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// writeFile writes content to dir/name, creating parent directories
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	writeJSON(t, filepath.Join(dir, name), content)
+}
+
+func inDir(dir string) *config.InputData {
+	return &config.InputData{Workspace: config.WorkspaceInfo{CurrentDir: dir}}
+}
+
+func TestPythonEnvSegment(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	t.Setenv("CONDA_DEFAULT_ENV", "")
+	project := filepath.Join(t.TempDir(), "api")
+	writeFile(t, project, ".venv/pyvenv.cfg", "home = /usr/bin\nversion_info = 3.12.1\n")
+	sub := filepath.Join(project, "src", "pkg")
+	os.MkdirAll(sub, 0755)
+
+	if got := (&segment.PythonEnvSegment{}).Collect(inDir(sub)).Primary; got != "api 3.12.1" {
+		t.Errorf("got %q from nearest .venv", got)
+	}
+
+	t.Setenv("CONDA_DEFAULT_ENV", "ml")
+	if got := (&segment.PythonEnvSegment{}).Collect(inDir(sub)).Primary; got != "ml" {
+		t.Errorf("got %q from conda", got)
+	}
+
+	t.Setenv("VIRTUAL_ENV", filepath.Join(t.TempDir(), "tools-env"))
+	if got := (&segment.PythonEnvSegment{}).Collect(inDir(sub)).Primary; got != "tools-env" {
+		t.Errorf("got %q from VIRTUAL_ENV", got)
+	}
+}
+
+func TestPythonEnvSegmentHidden(t *testing.T) {
+	t.Setenv("VIRTUAL_ENV", "")
+	t.Setenv("CONDA_DEFAULT_ENV", "")
+	if got := (&segment.PythonEnvSegment{}).Collect(inDir(t.TempDir())).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
+
+func TestGoVersionSegment(t *testing.T) {
+	module := t.TempDir()
+	writeFile(t, module, "go.mod", "module example.com/m\n\ngo 1.24.0\n\nrequire example.com/x v1.0.0\n")
+	sub := filepath.Join(module, "cmd", "app")
+	os.MkdirAll(sub, 0755)
+
+	if got := (&segment.GoVersionSegment{}).Collect(inDir(sub)).Primary; got != "1.24.0" {
+		t.Errorf("got %q", got)
+	}
+
+	writeFile(t, module, "go.mod", "module example.com/m\n\ngo 1.24.0\n\ntoolchain go1.25.3\n")
+	if got := (&segment.GoVersionSegment{}).Collect(inDir(sub)).Primary; got != "1.25.3" {
+		t.Errorf("got %q with toolchain", got)
+	}
+
+	writeFile(t, module, "go.mod", "module example.com/m\n\ngo 1.22.3 // pinned for CI\n")
+	if got := (&segment.GoVersionSegment{}).Collect(inDir(sub)).Primary; got != "1.22.3" {
+		t.Errorf("got %q with trailing comment", got)
+	}
+
+	if got := (&segment.GoVersionSegment{}).Collect(inDir(t.TempDir())).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
+
+func TestNodeVersionSegment(t *testing.T) {
+	repo := t.TempDir()
+	writeFile(t, repo, ".nvmrc", "v20.11.0\n")
+	writeFile(t, repo, "packages/web/package.json", `{"name":"web","engines":{"node":">=18"}}`)
+	writeFile(t, repo, "packages/lib/package.json", `{"name":"lib"}`)
+
+	if got := (&segment.NodeVersionSegment{}).Collect(inDir(filepath.Join(repo, "packages", "web"))).Primary; got != ">=18" {
+		t.Errorf("got %q from package.json engines", got)
+	}
+	if got := (&segment.NodeVersionSegment{}).Collect(inDir(filepath.Join(repo, "packages", "lib"))).Primary; got != "v20.11.0" {
+		t.Errorf("got %q from .nvmrc", got)
+	}
+	if got := (&segment.NodeVersionSegment{}).Collect(inDir(t.TempDir())).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
+
+func TestKubeContextSegment(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", "")
+	writeFile(t, home, ".kube/config", "apiVersion: v1\ncontexts:\n- context:\n    cluster: dev\n  name: dev\ncurrent-context: dev\nkind: Config\n")
+
+	if got := (&segment.KubeContextSegment{}).Collect(inDir(home)).Primary; got != "dev" {
+		t.Errorf("got %q from ~/.kube/config", got)
+	}
+
+	dir := t.TempDir()
+	writeFile(t, dir, "empty.yaml", "apiVersion: v1\nkind: Config\n")
+	writeFile(t, dir, "prod.yaml", "apiVersion: v1\ncurrent-context: \"prod-eu\"\n")
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "empty.yaml")+string(os.PathListSeparator)+filepath.Join(dir, "prod.yaml"))
+	if got := (&segment.KubeContextSegment{}).Collect(inDir(home)).Primary; got != "prod-eu" {
+		t.Errorf("got %q from KUBECONFIG", got)
+	}
+
+	t.Setenv("KUBECONFIG", filepath.Join(dir, "missing.yaml"))
+	if got := (&segment.KubeContextSegment{}).Collect(inDir(home)).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
+
+func TestCloudProfileSegment(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "prod")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "my-project")
+	if got := (&segment.CloudProfileSegment{}).Collect(inDir("")).Primary; got != "aws:prod · gcp:my-project" {
+		t.Errorf("got %q", got)
+	}
+
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	if got := (&segment.CloudProfileSegment{}).Collect(inDir("")).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+func collectFiles(path, projectDir string, opts config.FilesOptions) segment.SegmentData {
+	return (&segment.FilesSegment{Options: opts}).Collect(&config.InputData{
+		TranscriptPath: path,
+		Workspace:      config.WorkspaceInfo{CurrentDir: projectDir, ProjectDir: projectDir},
+	})
+}
+
+func TestFilesSegment(t *testing.T) {
+	project := t.TempDir()
+	path := writeTranscript(t,
+		userLine,
+		toolUseLine("t1", "Edit", map[string]string{"file_path": filepath.Join(project, "main.go")}),
+		toolUseLine("t2", "Read", map[string]string{"file_path": filepath.Join(project, "README.md")}),
+		toolUseLine("t3", "Write", map[string]string{"file_path": filepath.Join(project, "cmd", "api.go")}),
+		toolUseLine("t4", "NotebookEdit", map[string]string{"notebook_path": filepath.Join(project, "a.ipynb")}),
+		toolUseLine("t5", "MultiEdit", map[string]string{"file_path": filepath.Join(project, "cmd", "api.go")}),
+		toolUseLine("t6", "Edit", map[string]string{"file_path": "/etc/hosts"}),
+		toolUseLine("t7", "Edit", map[string]string{"file_path": filepath.Join(project, "main.go")}),
+	)
+
+	data := collectFiles(path, project, config.FilesOptions{})
+	if want := "4 files · main.go"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+
+	// The latest file is shown as an absolute path when outside the project
+	path = writeTranscript(t, toolUseLine("t1", "Write", map[string]string{"file_path": "/tmp/notes.md"}))
+	if got := collectFiles(path, project, config.FilesOptions{}).Primary; got != "1 file · /tmp/notes.md" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFilesSegmentMarksUncommitted(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	commitFile(t, clone, "committed.go", "package main\n")
+	os.MkdirAll(filepath.Join(clone, "cmd"), 0755)
+	os.WriteFile(filepath.Join(clone, "cmd", "api.go"), []byte("package cmd\n"), 0644)
+
+	path := writeTranscript(t,
+		toolUseLine("t1", "Write", map[string]string{"file_path": filepath.Join(clone, "committed.go")}),
+		toolUseLine("t2", "Write", map[string]string{"file_path": filepath.Join(clone, "cmd", "api.go")}),
+	)
+
+	data := collectFiles(path, clone, config.FilesOptions{MarkUncommitted: true})
+	if want := "2 files (1 dirty) · cmd/api.go*"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+	if got := collectFiles(path, clone, config.FilesOptions{}).Primary; got != "2 files · cmd/api.go" {
+		t.Errorf("got %q without marking", got)
+	}
+
+	// The cached status is invalidated once the file is committed.
+	commitFile(t, clone, "cmd/api.go", "package cmd\n")
+	if got := collectFiles(path, clone, config.FilesOptions{MarkUncommitted: true}).Primary; got != "2 files · cmd/api.go" {
+		t.Errorf("got %q after committing", got)
+	}
+}
+
+func TestFilesSegmentMarksUncommittedThroughSymlink(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	os.WriteFile(filepath.Join(clone, "..notes.md"), []byte("notes\n"), 0644)
+	os.WriteFile(filepath.Join(clone, "main.go"), []byte("package main\n"), 0644)
+
+	// Claude Code reports paths under the symlinked directory it was started in.
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(clone, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	path := writeTranscript(t,
+		toolUseLine("t1", "Write", map[string]string{"file_path": filepath.Join(link, "..notes.md")}),
+		toolUseLine("t2", "Write", map[string]string{"file_path": filepath.Join(link, "main.go")}),
+	)
+
+	data := collectFiles(path, link, config.FilesOptions{MarkUncommitted: true})
+	if want := "2 files (2 dirty) · main.go*"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+}
+
+func TestFilesSegmentNoEdits(t *testing.T) {
+	path := writeTranscript(t, userLine, toolUseLine("t1", "Read", map[string]string{"file_path": "/repo/main.go"}))
+	if got := collectFiles(path, "/repo", config.FilesOptions{}).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
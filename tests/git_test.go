@@ -0,0 +1,445 @@
+package tests
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// runGit runs a git command in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes a file and commits it
+func commitFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", "update "+name)
+}
+
+// setupTrackingRepo creates a bare remote and a clone tracking origin/main
+func setupTrackingRepo(t *testing.T) (remote, clone string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	root := t.TempDir()
+	remote = filepath.Join(root, "remote.git")
+	clone = filepath.Join(root, "clone")
+
+	runGit(t, root, "init", "-q", "--bare", "-b", "main", remote)
+	runGit(t, root, "clone", "-q", remote, clone)
+	runGit(t, clone, "checkout", "-q", "-b", "main")
+	commitFile(t, clone, "README.md", "hello\n")
+	runGit(t, clone, "push", "-q", "-u", "origin", "main")
+	return remote, clone
+}
+
+func collectGit(dir string) string {
+	return (&segment.GitSegment{}).Collect(&config.InputData{
+		Workspace: config.WorkspaceInfo{CurrentDir: dir},
+	}).Primary
+}
+
+func TestGitSegmentNotARepo(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if got := collectGit(t.TempDir()); got != "" {
+		t.Errorf("expected empty result outside a repo, got %q", got)
+	}
+}
+
+func TestGitSegmentReadsBranchWithoutGit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, ".git")
+	if err := os.MkdirAll(filepath.Join(gitDir, "refs", "heads"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature/native\n"), 0644)
+	os.WriteFile(filepath.Join(gitDir, "packed-refs"), []byte("# pack-refs with: peeled\n"+strings.Repeat("a", 40)+" refs/heads/feature/native\n"), 0644)
+
+	got := collectGit(filepath.Join(dir))
+	if !strings.HasPrefix(got, "feature/native") {
+		t.Errorf("expected branch from HEAD, got %q", got)
+	}
+}
+
+func TestGitSegmentCleanAndDirty(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+
+	if got := collectGit(clone); got != "main" {
+		t.Fatalf("expected clean main, got %q", got)
+	}
+
+	os.WriteFile(filepath.Join(clone, "new.txt"), []byte("x"), 0644)
+	runGit(t, clone, "add", "new.txt")
+	if got := collectGit(clone); got != "main *" {
+		t.Errorf("expected dirty main, got %q", got)
+	}
+}
+
+func TestGitSegmentSeesNewUntrackedFile(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+
+	if got := collectGit(clone); got != "main" {
+		t.Fatalf("expected clean main, got %q", got)
+	}
+	// Creating a file changes the worktree mtime, so the cached clean state is not reused.
+	os.WriteFile(filepath.Join(clone, "untracked.txt"), []byte("x"), 0644)
+	if got := collectGit(clone); got != "main *" {
+		t.Errorf("expected dirty main right after creating a file, got %q", got)
+	}
+}
+
+func TestGitSegmentStatusCacheSeconds(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	collect := func(seconds int) string {
+		return (&segment.GitSegment{Options: config.GitOptions{StatusCacheSeconds: seconds}}).Collect(&config.InputData{
+			Workspace: config.WorkspaceInfo{CurrentDir: clone},
+		}).Primary
+	}
+	if got := collect(60); got != "main" {
+		t.Fatalf("expected clean main, got %q", got)
+	}
+
+	// Rewriting a tracked file in place changes neither the index nor the root directory,
+	// so the cached clean state is reused until it expires.
+	file, err := os.OpenFile(filepath.Join(clone, "README.md"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("more\n")
+	file.Close()
+	if got := collect(60); got != "main" {
+		t.Errorf("expected the cached clean state within the TTL, got %q", got)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if got := collect(1); got != "main *" {
+		t.Errorf("expected dirty main after the TTL expired, got %q", got)
+	}
+}
+
+func TestGitSegmentHiddenWithoutCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+
+	if got := collectGit(dir); got != "" {
+		t.Errorf("expected hidden segment before the first commit, got %q", got)
+	}
+}
+
+func TestGitSegmentAheadBehind(t *testing.T) {
+	remote, clone := setupTrackingRepo(t)
+
+	// Another clone pushes two commits, making the first one behind.
+	other := filepath.Join(filepath.Dir(clone), "other")
+	runGit(t, filepath.Dir(clone), "clone", "-q", remote, other)
+	commitFile(t, other, "a.txt", "a\n")
+	commitFile(t, other, "b.txt", "b\n")
+	runGit(t, other, "push", "-q", "origin", "main")
+
+	runGit(t, clone, "fetch", "-q")
+	for i := 0; i < 3; i++ {
+		commitFile(t, clone, fmt.Sprintf("local%d.txt", i), "local\n")
+	}
+
+	want := "main ↑3 ↓2"
+	if got := collectGit(clone); got != want {
+		t.Errorf("loose objects: got %q, want %q", got, want)
+	}
+
+	// Same result when everything lives in a packfile.
+	runGit(t, clone, "gc", "-q", "--aggressive")
+	runGit(t, clone, "pack-refs", "--all")
+	if got := collectGit(clone); got != want {
+		t.Errorf("packed objects: got %q, want %q", got, want)
+	}
+}
+
+// writeDeltaPack writes a pack holding a commit and an OFS_DELTA against it, indexed as sha
+func writeDeltaPack(t *testing.T, gitDir, sha string, delta []byte) {
+	t.Helper()
+	deflate := func(data []byte) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+		return buf.Bytes()
+	}
+	// Object header: type in bits 4-6, size as a little-endian varint (4 bits, then 7 per byte).
+	header := func(kind byte, size int) []byte {
+		b := []byte{kind<<4 | byte(size&0x0f)}
+		for size >>= 4; size > 0; size >>= 7 {
+			b[len(b)-1] |= 0x80
+			b = append(b, byte(size&0x7f))
+		}
+		return b
+	}
+
+	base := []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nbase\n")
+	pack := []byte("PACK\x00\x00\x00\x02\x00\x00\x00\x02")
+	pack = append(pack, header(1, len(base))...)
+	pack = append(pack, deflate(base)...)
+	deltaOffset := len(pack)
+	pack = append(pack, header(6, len(delta))...)
+	pack = append(pack, byte(deltaOffset-12)) // distance back to the base object
+	pack = append(pack, deflate(delta)...)
+	pack = append(pack, make([]byte, 20)...)
+
+	raw, _ := hex.DecodeString(sha)
+	idx := []byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}
+	for i := 0; i < 256; i++ {
+		count := uint32(0)
+		if i >= int(raw[0]) {
+			count = 1
+		}
+		idx = binary.BigEndian.AppendUint32(idx, count)
+	}
+	idx = append(idx, raw...)
+	idx = append(idx, 0, 0, 0, 0) // crc32
+	idx = binary.BigEndian.AppendUint32(idx, uint32(deltaOffset))
+	idx = append(idx, make([]byte, 40)...)
+
+	dir := filepath.Join(gitDir, "objects", "pack")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "pack-test.pack"), pack, 0644)
+	os.WriteFile(filepath.Join(dir, "pack-test.idx"), idx, 0644)
+}
+
+func TestGitSegmentRejectsOversizedDelta(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	gitDir := filepath.Join(clone, ".git")
+	sha := strings.Repeat("b", 40)
+
+	// The delta claims a 1 TiB result; allocating it would crash the status line.
+	base := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nbase\n"
+	delta := []byte{byte(len(base)), 0x80, 0x80, 0x80, 0x80, 0x80, 0x20, 1, 'x'}
+	writeDeltaPack(t, gitDir, sha, delta)
+	os.WriteFile(filepath.Join(gitDir, "refs", "heads", "main"), []byte(sha+"\n"), 0644)
+
+	if got := collectGit(clone); !strings.HasPrefix(got, "main") {
+		t.Errorf("expected the branch despite the corrupt delta, got %q", got)
+	}
+}
+
+func TestGitSegmentAheadBehindWithSkewedClocks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	commitAt := func(date string, args ...string) string {
+		t.Setenv("GIT_COMMITTER_DATE", date)
+		t.Setenv("GIT_AUTHOR_DATE", date)
+		return runGit(t, dir, append([]string{"commit-tree", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", "-m", date}, args...)...)
+	}
+
+	// The merge base's parent is newer than the merge base itself, and the local
+	// merge commit reaches that parent directly, so a walk stopping at the first
+	// common commit would count it as local-only.
+	parent := commitAt("2018-01-01T00:00:00Z")
+	base := commitAt("2015-01-01T00:00:00Z", "-p", parent)
+	upstream := commitAt("2021-01-01T00:00:00Z", "-p", base)
+	local := commitAt("2022-01-01T00:00:00Z", "-p", base, "-p", parent)
+
+	runGit(t, dir, "update-ref", "refs/heads/up", upstream)
+	runGit(t, dir, "update-ref", "refs/heads/main", local)
+	runGit(t, dir, "config", "branch.main.remote", ".")
+	runGit(t, dir, "config", "branch.main.merge", "refs/heads/up")
+
+	want := runGit(t, dir, "rev-list", "--left-right", "--count", "up...main")
+	if want != "1\t1" {
+		t.Fatalf("unexpected git result %q", want)
+	}
+	if got := collectGit(dir); got != "main ↑1 ↓1" {
+		t.Errorf("got %q, want main ↑1 ↓1", got)
+	}
+}
+
+func TestGitSegmentFromSubdirectory(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	sub := filepath.Join(clone, "pkg", "inner")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := collectGit(sub); !strings.HasPrefix(got, "main") {
+		t.Errorf("expected branch from subdirectory, got %q", got)
+	}
+}
+
+func TestGitSegmentLinkedWorktree(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	wt := filepath.Join(filepath.Dir(clone), "wt")
+	runGit(t, clone, "worktree", "add", "-q", "-b", "topic", wt)
+	commitFile(t, wt, "topic.txt", "topic\n")
+
+	if got := collectGit(wt); !strings.HasPrefix(got, "topic") {
+		t.Errorf("expected worktree branch, got %q", got)
+	}
+}
+
+func TestGitSegmentWorktreeContext(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	wt := filepath.Join(filepath.Dir(clone), "review")
+	runGit(t, clone, "worktree", "add", "-q", "-b", "topic", wt)
+
+	seg := &segment.GitSegment{Options: config.DefaultGitOptions()}
+	data := seg.Collect(&config.InputData{Workspace: config.WorkspaceInfo{CurrentDir: wt}})
+	if data.Primary != "topic [wt:review]" {
+		t.Errorf("got %q, want %q", data.Primary, "topic [wt:review]")
+	}
+	if data.Metadata["worktree"] != "review" {
+		t.Errorf("expected worktree metadata, got %v", data.Metadata)
+	}
+
+	// The main working tree carries no worktree tag.
+	data = seg.Collect(&config.InputData{Workspace: config.WorkspaceInfo{CurrentDir: clone}})
+	if strings.Contains(data.Primary, "wt:") {
+		t.Errorf("main worktree should not be tagged, got %q", data.Primary)
+	}
+}
+
+func TestGitSegmentSubmoduleContext(t *testing.T) {
+	remote, clone := setupTrackingRepo(t)
+	super := filepath.Join(filepath.Dir(clone), "app")
+	runGit(t, filepath.Dir(clone), "init", "-q", "-b", "main", super)
+	commitFile(t, super, "go.mod", "module app\n")
+	runGit(t, super, "-c", "protocol.file.allow=always", "submodule", "add", "-q", remote, "libs/core")
+	runGit(t, super, "commit", "-q", "-m", "add submodule")
+
+	sub := filepath.Join(super, "libs", "core")
+	seg := &segment.GitSegment{Options: config.DefaultGitOptions()}
+	data := seg.Collect(&config.InputData{Workspace: config.WorkspaceInfo{CurrentDir: sub}})
+	if !strings.HasSuffix(data.Primary, "[sub:libs/core@app]") {
+		t.Errorf("expected submodule context, got %q", data.Primary)
+	}
+	if data.Metadata["superproject"] != "app" || data.Metadata["submodule"] != "libs/core" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+
+	seg.Options.ShowSubmodule = false
+	data = seg.Collect(&config.InputData{Workspace: config.WorkspaceInfo{CurrentDir: sub}})
+	if strings.Contains(data.Primary, "sub:") {
+		t.Errorf("submodule context should be hidden, got %q", data.Primary)
+	}
+}
+
+func TestGitSegmentChildRepos(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	root := t.TempDir()
+	for _, name := range []string{"api", "web", "docs"} {
+		dir := filepath.Join(root, name)
+		runGit(t, root, "init", "-q", "-b", "main", dir)
+		commitFile(t, dir, "README.md", name+"\n")
+	}
+	os.WriteFile(filepath.Join(root, "web", "dirty.txt"), []byte("x"), 0644)
+	os.MkdirAll(filepath.Join(root, "notes"), 0755)
+
+	input := &config.InputData{Workspace: config.WorkspaceInfo{CurrentDir: root}}
+	if got := (&segment.GitSegment{}).Collect(input).Primary; got != "" {
+		t.Errorf("child scan is opt-in, got %q", got)
+	}
+
+	seg := &segment.GitSegment{Options: config.GitOptions{ScanChildRepos: true}}
+	data := seg.Collect(input)
+	if data.Primary != "3 repos · 1 dirty" {
+		t.Errorf("got %q, want %q", data.Primary, "3 repos · 1 dirty")
+	}
+	if data.Metadata["dirty_repos"] != "web" {
+		t.Errorf("expected dirty_repos=web, got %v", data.Metadata)
+	}
+}
+
+func collectGitDiff(dir string, opts config.GitDiffOptions) segment.SegmentData {
+	return (&segment.GitDiffSegment{Options: opts}).Collect(&config.InputData{
+		Workspace: config.WorkspaceInfo{CurrentDir: dir},
+	})
+}
+
+func TestGitDiffSegmentAgainstOriginHead(t *testing.T) {
+	remote, _ := setupTrackingRepo(t)
+
+	// A fresh clone records refs/remotes/origin/HEAD.
+	clone := filepath.Join(t.TempDir(), "work")
+	runGit(t, filepath.Dir(clone), "clone", "-q", remote, clone)
+
+	if got := collectGitDiff(clone, config.GitDiffOptions{}).Primary; got != "" {
+		t.Errorf("expected no diff on the base branch, got %q", got)
+	}
+
+	runGit(t, clone, "checkout", "-q", "-b", "agent/feature")
+	commitFile(t, clone, "a.go", "package a\n\nfunc A() {}\n")
+	commitFile(t, clone, "README.md", "bye\n")
+
+	data := collectGitDiff(clone, config.GitDiffOptions{})
+	if data.Primary != "2c +4/−1 (2f)" {
+		t.Errorf("got %q, want %q", data.Primary, "2c +4/−1 (2f)")
+	}
+	if data.Metadata["base"] != "origin/main" {
+		t.Errorf("expected base origin/main, got %q", data.Metadata["base"])
+	}
+
+	// Uncommitted work is included in the diff against the merge-base.
+	os.WriteFile(filepath.Join(clone, "README.md"), []byte("bye\nagain\n"), 0644)
+	runGit(t, clone, "add", "README.md")
+	if got := collectGitDiff(clone, config.GitDiffOptions{}).Primary; got != "2c +5/−1 (2f)" {
+		t.Errorf("got %q, want %q", got, "2c +5/−1 (2f)")
+	}
+}
+
+func TestGitDiffSegmentConfiguredBase(t *testing.T) {
+	_, clone := setupTrackingRepo(t)
+	runGit(t, clone, "checkout", "-q", "-b", "develop")
+	commitFile(t, clone, "dev.txt", "dev\n")
+	runGit(t, clone, "checkout", "-q", "-b", "topic")
+	commitFile(t, clone, "topic.txt", "topic\n")
+
+	if got := collectGitDiff(clone, config.GitDiffOptions{BaseBranch: "develop"}).Primary; got != "1c +1/−0 (1f)" {
+		t.Errorf("against develop: got %q", got)
+	}
+	if got := collectGitDiff(clone, config.GitDiffOptions{}).Primary; got != "2c +2/−0 (2f)" {
+		t.Errorf("against origin/main: got %q", got)
+	}
+	if got := collectGitDiff(clone, config.GitDiffOptions{BaseBranch: "missing"}).Primary; got != "" {
+		t.Errorf("unknown base should hide the segment, got %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// writeJSON writes content to path, creating parent directories
+func writeJSON(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupMCPConfig configures servers in every supported scope and returns the project directory
+func setupMCPConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+
+	writeJSON(t, filepath.Join(home, ".claude.json"), `{
+		"mcpServers": {"github": {"command": "gh-mcp"}},
+		"projects": {
+			`+`"`+filepath.ToSlash(project)+`"`+`: {"mcpServers": {"local-db": {"command": "db-mcp"}}, "disabledMcpjsonServers": ["legacy"]},
+			"/other": {"mcpServers": {"other": {"command": "x"}}}
+		}
+	}`)
+	writeJSON(t, filepath.Join(home, ".claude", "settings.json"), `{"mcpServers": {"github": {"command": "gh-mcp"}}}`)
+	writeJSON(t, filepath.Join(project, ".mcp.json"), `{"mcpServers": {"linear": {"url": "https://mcp.linear.app/sse"}, "legacy": {"command": "old"}}}`)
+	return project
+}
+
+func collectMCP(path, project string) segment.SegmentData {
+	return (&segment.MCPSegment{}).Collect(&config.InputData{
+		TranscriptPath: path,
+		Workspace:      config.WorkspaceInfo{CurrentDir: project, ProjectDir: project},
+	})
+}
+
+func TestMCPSegment(t *testing.T) {
+	project := setupMCPConfig(t)
+	path := writeTranscript(t,
+		userLine,
+		toolUseLine("t1", "mcp__linear__list_issues", map[string]string{}),
+		toolUseLine("t2", "mcp__local-db__query", map[string]string{"sql": "select 1"}),
+		toolUseLine("t3", "mcp__linear__get_issue", map[string]string{"id": "ENG-1"}),
+		toolUseLine("t4", "mcp__plugin_sentry__search", map[string]string{}),
+		toolUseLine("t5", "Bash", map[string]string{"command": "ls"}),
+	)
+
+	data := collectMCP(path, project)
+	if want := "3 servers · used: linear, local-db, plugin_sentry?"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+	if data.Metadata["servers"] != "github,linear,local-db" || data.Metadata["unconfigured"] != "plugin_sentry" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestMCPSegmentConfiguredOnly(t *testing.T) {
+	project := setupMCPConfig(t)
+	if got := collectMCP(writeTranscript(t, userLine), project).Primary; got != "3 servers" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMCPSegmentNoServers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if got := collectMCP(writeTranscript(t, userLine), t.TempDir()).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
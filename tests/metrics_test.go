@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/metrics"
+	"github.com/WAY29/cchline/segment"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	snap := metrics.Snapshot{
+		Project: `my "app"`,
+		Model:   "claude-sonnet-4",
+		Metrics: []metrics.Metric{{Name: "cchline_context_tokens", Help: "Tokens.", Value: 31200}},
+	}
+
+	var out bytes.Buffer
+	if err := metrics.Write(&out, snap); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP cchline_context_tokens Tokens.\n" +
+		"# TYPE cchline_context_tokens gauge\n" +
+		`cchline_context_tokens{project="my \"app\"",model="claude-sonnet-4"} 31200` + "\n" +
+		"# EOF\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestMetricsCollect(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTranscript(t, userLine, assistantLine(100, 200, 900, 30000))
+	input := &config.InputData{
+		Model:          config.ModelInfo{ID: "claude-sonnet-4-20250514"},
+		Workspace:      config.WorkspaceInfo{CurrentDir: filepath.Join(t.TempDir(), "sub"), ProjectDir: "/work/api"},
+		TranscriptPath: path,
+		Cost:           config.CostInfo{TotalCostUSD: 1.25},
+	}
+
+	// Already collected segments are reused instead of recomputed.
+	results := []segment.SegmentResult{{
+		ID:   config.SegmentGit,
+		Data: segment.SegmentData{Primary: "main *", Metadata: map[string]string{"dirty": "true"}},
+	}}
+	cfg := &config.SimpleConfig{
+		SegmentOrder:   []string{"context_window", "cost", "git"},
+		SegmentEnabled: []bool{true, true, true},
+	}
+	snap := metrics.Collect(cfg, input, nil, results)
+	if snap.Project != "api" || snap.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("unexpected labels %q %q", snap.Project, snap.Model)
+	}
+
+	values := make(map[string]float64)
+	for _, m := range snap.Metrics {
+		values[m.Name] = m.Value
+	}
+	for name, want := range map[string]float64{
+		"cchline_context_tokens":       31200,
+		"cchline_context_limit_tokens": 200000,
+		"cchline_session_cost_usd":     1.25,
+		"cchline_git_dirty":            1,
+	} {
+		if got, ok := values[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", name, got, ok, want)
+		}
+	}
+	if _, ok := values["cchline_cch_today_cost_usd"]; ok {
+		t.Error("CCH metrics should be omitted without a client")
+	}
+}
+
+func TestMetricsCollectSkipsDisabledSegments(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	input := &config.InputData{
+		Workspace:      config.WorkspaceInfo{CurrentDir: t.TempDir()},
+		TranscriptPath: writeTranscript(t, userLine, assistantLine(100, 200, 900, 30000)),
+		Cost:           config.CostInfo{TotalCostUSD: 1.25},
+	}
+	cfg := &config.SimpleConfig{
+		SegmentOrder:   []string{"context_window", "cost", "git"},
+		SegmentEnabled: []bool{true, false, false},
+	}
+
+	snap := metrics.Collect(cfg, input, nil, nil)
+	names := make(map[string]bool)
+	for _, m := range snap.Metrics {
+		names[m.Name] = true
+	}
+	if !names["cchline_context_tokens"] || !names["cchline_last_render_timestamp_seconds"] {
+		t.Errorf("enabled segment metrics missing: %v", names)
+	}
+	if names["cchline_session_cost_usd"] || names["cchline_git_dirty"] {
+		t.Errorf("disabled segments should not be exported: %v", names)
+	}
+}
+
+func TestMetricsProjectPath(t *testing.T) {
+	api := &config.InputData{Workspace: config.WorkspaceInfo{ProjectDir: "/work/api", CurrentDir: "/work/api/sub"}}
+	web := &config.InputData{Workspace: config.WorkspaceInfo{ProjectDir: "/work/web"}}
+
+	path := metrics.ProjectPath("/textfile/cchline.prom", api)
+	if filepath.Dir(path) != "/textfile" || !strings.HasPrefix(filepath.Base(path), "cchline-") || filepath.Ext(path) != ".prom" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if path != metrics.ProjectPath("/textfile/cchline.prom", api) {
+		t.Error("path should be stable for the same project")
+	}
+	if path == metrics.ProjectPath("/textfile/cchline.prom", web) {
+		t.Error("different projects should write different files")
+	}
+}
+
+func TestMetricsWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "textfile")
+	path := filepath.Join(dir, "cchline.prom")
+	snap := metrics.Snapshot{Metrics: []metrics.Metric{{Name: "cchline_git_dirty", Help: "Dirty.", Value: 0}}}
+
+	for i := 0; i < 2; i++ {
+		if err := metrics.WriteFile(path, snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("metrics file should be world readable, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), "# EOF\n") {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestLoadConfigMetricsOptions(t *testing.T) {
+	writeTestConfig(t, "[metrics]\nenabled = true\npath = \"/var/lib/node_exporter/textfile/cchline.prom\"\n")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := config.MetricsOptions{Enabled: true, Path: "/var/lib/node_exporter/textfile/cchline.prom"}
+	if cfg.Metrics != want {
+		t.Errorf("got %+v, want %+v", cfg.Metrics, want)
+	}
+}
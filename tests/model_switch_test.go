@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+const (
+	opusID   = "claude-opus-4-20250514"
+	sonnetID = "claude-sonnet-4-20250514"
+)
+
+func collectModel(path, modelID, displayName string) segment.SegmentData {
+	return collectModelWithOptions(config.ModelOptions{}, path, modelID, displayName)
+}
+
+func collectModelWithOptions(opts config.ModelOptions, path, modelID, displayName string) segment.SegmentData {
+	return (&segment.ModelSegment{Options: opts}).Collect(&config.InputData{
+		Model:          config.ModelInfo{ID: modelID, DisplayName: displayName},
+		TranscriptPath: path,
+	})
+}
+
+func modelLine(model, id string) string {
+	return pricedAssistantLine(model, id, "req_"+id, `{"input_tokens":1,"output_tokens":1}`)
+}
+
+func TestModelSegmentDetectsFallback(t *testing.T) {
+	path := writeTranscript(t,
+		userLine,
+		modelLine(opusID, "m1"),
+		modelLine(opusID, "m2"),
+		modelLine("<synthetic>", "m3"),
+		modelLine(sonnetID, "m4"),
+		sidechainLine("m5", 1, 1),
+	)
+
+	data := collectModel(path, opusID, "Opus 4")
+	if want := "Opus 4 ⇢ Sonnet 4"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+	if data.Metadata["answered_model"] != sonnetID || data.Metadata["mismatch"] != "true" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+	if _, ok := data.Metadata["switches"]; ok {
+		t.Errorf("switches should not be counted by default, got %v", data.Metadata)
+	}
+}
+
+func TestModelSegmentIgnoresRewrittenIDs(t *testing.T) {
+	// Proxies and cloud providers report the same model under different IDs.
+	for _, answered := range []string{
+		"anthropic/claude-sonnet-4",
+		"us.anthropic.claude-sonnet-4-20250514-v1:0",
+		"claude-sonnet-4@20250514",
+		"claude-sonnet-4[1m]",
+		"Claude-Sonnet-4-Latest",
+	} {
+		path := writeTranscript(t, userLine, modelLine(answered, "m1"))
+		data := collectModel(path, sonnetID, "Sonnet 4")
+		if data.Primary != "Sonnet 4" || data.Metadata["mismatch"] != "" {
+			t.Errorf("%s: got %q %v, want no mismatch", answered, data.Primary, data.Metadata)
+		}
+	}
+
+	// Rewritten IDs of the same model do not count as switches either.
+	path := writeTranscript(t, modelLine(sonnetID, "m1"), modelLine("anthropic/claude-sonnet-4", "m2"))
+	opts := config.ModelOptions{ShowSwitches: true}
+	if got := collectModelWithOptions(opts, path, sonnetID, "Sonnet 4").Primary; got != "Sonnet 4" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestModelSegmentCountsSwitches(t *testing.T) {
+	path := writeTranscript(t,
+		modelLine(sonnetID, "m1"),
+		modelLine(opusID, "m2"),
+		modelLine(sonnetID, "m3"),
+	)
+
+	if got := collectModel(path, sonnetID, "Sonnet 4").Primary; got != "Sonnet 4" {
+		t.Errorf("got %q with show_switches disabled", got)
+	}
+
+	opts := config.ModelOptions{ShowSwitches: true}
+	data := collectModelWithOptions(opts, path, sonnetID, "Sonnet 4")
+	if data.Primary != "Sonnet 4 · 2 switches" || data.Metadata["switches"] != "2" {
+		t.Errorf("got %q %v", data.Primary, data.Metadata)
+	}
+	if got := collectModelWithOptions(opts, writeTranscript(t, modelLine(sonnetID, "m1")), sonnetID, "Sonnet 4").Primary; got != "Sonnet 4" {
+		t.Errorf("got %q without switches", got)
+	}
+}
+
+func TestModelSegmentWaitsForAnswerAfterSwitch(t *testing.T) {
+	lines := []string{
+		userLine,
+		modelLine(opusID, "m1"),
+		// The user runs /model sonnet and asks again; Sonnet has not answered yet.
+		userLine,
+	}
+	path := writeTranscript(t, lines...)
+
+	data := collectModel(path, sonnetID, "Sonnet 4")
+	if data.Primary != "Sonnet 4" || data.Metadata["mismatch"] != "" {
+		t.Errorf("got %q %v, want no mismatch before the new model answers", data.Primary, data.Metadata)
+	}
+
+	// Once the switched model answers, the switch is counted without a mismatch.
+	path = writeTranscript(t, append(lines, modelLine(sonnetID, "m2"))...)
+	opts := config.ModelOptions{ShowSwitches: true}
+	if got := collectModelWithOptions(opts, path, sonnetID, "Sonnet 4").Primary; got != "Sonnet 4 · 1 switch" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/render"
+	"github.com/WAY29/cchline/segment"
+)
+
+func TestProgressBarStyles(t *testing.T) {
+	tests := []struct {
+		name    string
+		percent float64
+		opts    config.ProgressBarOptions
+		want    string
+	}{
+		{"blocks", 50, config.ProgressBarOptions{Width: 10}, "▰▰▰▰▰▱▱▱▱▱"},
+		{"eighths", 55, config.ProgressBarOptions{Width: 4, Style: config.ProgressBarStyleEighths}, "██▎░"},
+		{"ascii", 30, config.ProgressBarOptions{Width: 10, Style: config.ProgressBarStyleASCII}, "[###-------]"},
+		{"custom glyphs", 40, config.ProgressBarOptions{Width: 5, Filled: "=", Empty: "."}, "==..."},
+		{"clamp high", 150, config.ProgressBarOptions{Width: 3}, "▰▰▰"},
+		{"clamp low", -5, config.ProgressBarOptions{Width: 3}, "▱▱▱"},
+		{"default width", 0, config.ProgressBarOptions{}, strings.Repeat("▱", 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := render.ProgressBar(tt.percent, tt.opts); got != tt.want {
+				t.Errorf("ProgressBar(%v) = %q, want %q", tt.percent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWithProgressBar(t *testing.T) {
+	seg := segment.SegmentResult{
+		ID: config.SegmentContextWindow,
+		Data: segment.SegmentData{
+			Primary:  "50.0% · 100K tokens",
+			Metadata: map[string]string{"percent": "50.0"},
+		},
+	}
+
+	cfg := createTestConfig(config.ThemeModeDefault, " | ")
+	if got := render.NewStatusLineGenerator(cfg).Generate([]segment.SegmentResult{seg}); strings.Contains(got, "▰") {
+		t.Errorf("progress bar should be opt-in, got %q", got)
+	}
+
+	cfg.ProgressBar = config.DefaultProgressBarOptions()
+	cfg.ProgressBar.Segments = []string{string(config.SegmentContextWindow)}
+	got := render.NewStatusLineGenerator(cfg).Generate([]segment.SegmentResult{seg})
+	if !strings.Contains(got, "▰▰▰▰▰▱▱▱▱▱") || !strings.Contains(got, "50.0%") {
+		t.Errorf("expected progress bar before text, got %q", got)
+	}
+
+	// Segments without percent metadata render unchanged.
+	seg.Data.Metadata = nil
+	got = render.NewStatusLineGenerator(cfg).Generate([]segment.SegmentResult{seg})
+	if strings.Contains(got, "▱") {
+		t.Errorf("missing percent should not render a bar, got %q", got)
+	}
+}
+
+func TestProgressBarColorsHigherIsBetter(t *testing.T) {
+	cfg := createTestConfig(config.ThemeModeDefault, " | ")
+	cfg.ProgressBar = config.DefaultProgressBarOptions()
+	cfg.ProgressBar.Segments = []string{string(config.SegmentCache)}
+	opts := cfg.ProgressBar
+
+	render95 := func(scale string) string {
+		return render.NewStatusLineGenerator(cfg).Generate([]segment.SegmentResult{{
+			ID: config.SegmentCache,
+			Data: segment.SegmentData{
+				Primary:  "95% (90%)",
+				Metadata: map[string]string{"percent": "95", "percent_scale": scale},
+			},
+		}})
+	}
+
+	// A 95% cache hit rate is healthy and must not be colored critical.
+	green := config.ApplyColor(render.ProgressBar(95, opts), config.PercentColor(0, opts.WarnThreshold, opts.CriticalThreshold))
+	if got := render95("higher_is_better"); !strings.Contains(got, green) {
+		t.Errorf("expected a green bar, got %q", got)
+	}
+	red := config.ApplyColor(render.ProgressBar(95, opts), config.PercentColor(100, opts.WarnThreshold, opts.CriticalThreshold))
+	if got := render95(""); !strings.Contains(got, red) {
+		t.Errorf("expected a red bar without percent_scale, got %q", got)
+	}
+}
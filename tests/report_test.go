@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/report"
+	"github.com/WAY29/cchline/segment"
+)
+
+func reportEntries() []segment.UsageEntry {
+	day := func(d, hour int) time.Time { return time.Date(2025, 6, d, hour, 0, 0, 0, time.Local) }
+	return []segment.UsageEntry{
+		{Time: day(2, 9), SessionID: "a", Project: "/work/api", Model: "claude-sonnet-4-20250514", Output: 100000},
+		{Time: day(2, 11), SessionID: "a", Project: "/work/api", Model: "claude-opus-4-20250514", Output: 10000},
+		{Time: day(4, 9), SessionID: "b", Project: "/work/web", Model: "claude-sonnet-4-20250514", Input: 1000, CacheRead: 100000},
+		{Time: day(9, 9), SessionID: "c", Project: "/work/api", Model: "claude-sonnet-4-20250514", Output: 200000},
+	}
+}
+
+func TestReportBuildDaily(t *testing.T) {
+	rows := report.Build(reportEntries(), report.Options{Mode: report.ModeDaily})
+	if len(rows) != 3 || rows[0].Key != "2025-06-02" || rows[2].Key != "2025-06-09" {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+
+	// sonnet $1.50 + opus $0.75, most expensive model first.
+	first := rows[0]
+	if first.MessageCount != 2 || first.TotalTokens != 110000 || first.Cost < 2.249 || first.Cost > 2.251 {
+		t.Errorf("unexpected totals %+v", first.Usage)
+	}
+	if len(first.Models) != 2 || first.Models[0].Model != "claude-sonnet-4-20250514" {
+		t.Errorf("unexpected model breakdown %+v", first.Models)
+	}
+}
+
+func TestReportBuildGroupingAndFilters(t *testing.T) {
+	weekly := report.Build(reportEntries(), report.Options{Mode: report.ModeWeekly})
+	if len(weekly) != 2 || weekly[0].Key != "2025-06-02" || weekly[1].Key != "2025-06-09" {
+		t.Errorf("weeks should start on Monday, got %+v", weekly)
+	}
+
+	monthly := report.Build(reportEntries(), report.Options{Mode: report.ModeMonthly})
+	if len(monthly) != 1 || monthly[0].Key != "2025-06" || monthly[0].MessageCount != 4 {
+		t.Errorf("unexpected monthly rows %+v", monthly)
+	}
+
+	sessions := report.Build(reportEntries(), report.Options{Mode: report.ModeSession, Project: "api"})
+	if len(sessions) != 2 || sessions[0].Key != "a" || sessions[0].Project != "/work/api" || sessions[1].Key != "c" {
+		t.Errorf("unexpected session rows %+v", sessions)
+	}
+
+	models := report.Build(reportEntries(), report.Options{
+		Mode:  report.ModeModel,
+		Since: time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local),
+		Until: time.Date(2025, 6, 4, 0, 0, 0, 0, time.Local),
+	})
+	if len(models) != 2 || models[0].Key != "claude-sonnet-4-20250514" || models[0].MessageCount != 2 {
+		t.Errorf("--until should include the whole day, got %+v", models)
+	}
+}
+
+func TestReportRunOutputs(t *testing.T) {
+	dir := setupProjects(t)
+	appendLines(t, filepath.Join(dir, "-work-app", "s.jsonl"),
+		usageLine("1", time.Date(2025, 6, 2, 9, 0, 0, 0, time.Local))+"\n"+
+			usageLine("2", time.Date(2025, 6, 3, 9, 0, 0, 0, time.Local))+"\n")
+	cfg := &config.SimpleConfig{}
+
+	var out bytes.Buffer
+	if err := report.Run([]string{"daily", "--json"}, cfg, &out); err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Rows   []report.Row `json:"rows"`
+		Totals report.Usage `json:"totals"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if len(parsed.Rows) != 2 || parsed.Totals.Cost != 3 {
+		t.Errorf("unexpected JSON report %+v", parsed)
+	}
+
+	out.Reset()
+	if err := report.Run([]string{"--since", "20250603", "--csv", "--breakdown"}, cfg, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "date,models,input_tokens,output_tokens,cache_creation_tokens,cache_read_tokens,total_tokens,cost_usd\n" +
+		"2025-06-03,claude-sonnet-4-20250514,0,100000,0,0,100000,1.5000\n"
+	if out.String() != want {
+		t.Errorf("got CSV\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := report.Run([]string{"monthly"}, cfg, &out); err != nil {
+		t.Fatal(err)
+	}
+	if table := out.String(); !strings.Contains(table, "2025-06") || !strings.Contains(table, "200,000") || !strings.Contains(table, "$3.00") {
+		t.Errorf("unexpected table\n%s", table)
+	}
+}
+
+func TestReportRunFlagsAfterMode(t *testing.T) {
+	setupProjects(t)
+	cfg := &config.SimpleConfig{}
+	var out bytes.Buffer
+	// Flags after the mode must not be dropped.
+	if err := report.Run([]string{"--since", "2025-06-01", "session", "--json"}, cfg, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); !strings.HasPrefix(got, "{") {
+		t.Errorf("expected JSON output, got %q", got)
+	}
+}
+
+func TestReportRunErrors(t *testing.T) {
+	setupProjects(t)
+	cfg := &config.SimpleConfig{}
+	for _, args := range [][]string{{"yearly"}, {"--since", "June"}, {"--json", "--csv"}, {"daily", "weekly"}, {"--since", "2025-06-01", "session", "extra"}} {
+		if err := report.Run(args, cfg, &bytes.Buffer{}); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+const (
+	commandPromptLine = `{"type":"user","message":{"role":"user","content":"<command-name>/clear</command-name>\n<command-message>clear</command-message>"}}`
+	blockPromptLine   = `{"type":"user","message":{"role":"user","content":[{"type":"text","text":"Fix the login\nredirect loop in auth middleware"}]}}`
+	summaryLine       = `{"type":"summary","summary":"Login redirect fix","leafUuid":"u-1"}`
+)
+
+func collectSessionTitle(path string, maxWidth int) segment.SegmentData {
+	return (&segment.SessionTitleSegment{Options: config.SessionTitleOptions{MaxWidth: maxWidth}}).Collect(&config.InputData{
+		TranscriptPath: path,
+	})
+}
+
+func TestSessionTitleSegmentUsesSummary(t *testing.T) {
+	data := collectSessionTitle(writeTranscript(t, summaryLine, blockPromptLine, assistantLine(1, 2, 0, 0)), 30)
+	if data.Primary != "Login redirect fix" || data.Metadata["source"] != "summary" {
+		t.Errorf("got %q (%v)", data.Primary, data.Metadata)
+	}
+}
+
+func TestSessionTitleSegmentFallsBackToFirstPrompt(t *testing.T) {
+	path := writeTranscript(t, commandPromptLine, blockPromptLine, assistantLine(1, 2, 0, 0), userLine)
+
+	data := collectSessionTitle(path, 20)
+	if data.Primary != "Fix the login redir…" || data.Metadata["source"] != "prompt" {
+		t.Errorf("got %q (%v)", data.Primary, data.Metadata)
+	}
+	if data.Metadata["title"] != "Fix the login redirect loop in auth middleware" {
+		t.Errorf("unexpected title %q", data.Metadata["title"])
+	}
+}
+
+func TestSessionTitleSegmentEmptyTranscript(t *testing.T) {
+	if got := collectSessionTitle(writeTranscript(t, commandPromptLine), 30).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
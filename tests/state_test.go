@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// promptLine builds a user prompt with a uuid identifying the turn
+func promptLine(uuid string) string {
+	return fmt.Sprintf(`{"type":"user","uuid":%q,"message":{"role":"user","content":"next"}}`, uuid)
+}
+
+func readSessionStates(t *testing.T) map[string]json.RawMessage {
+	t.Helper()
+	var states map[string]json.RawMessage
+	data, err := os.ReadFile(filepath.Join(config.CacheDir(), "session-state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		t.Fatal(err)
+	}
+	return states
+}
+
+func TestContextWindowDelta(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl")
+	seg := &segment.ContextWindowSegment{Options: config.ContextWindowOptions{ShowDelta: true}}
+	collect := func(lines ...string) string {
+		os.WriteFile(path, []byte(joinLines(lines)), 0644)
+		return seg.Collect(&config.InputData{
+			SessionID:      "s1",
+			Model:          config.ModelInfo{ID: "claude-sonnet-4"},
+			TranscriptPath: path,
+		}).Primary
+	}
+
+	turn1 := []string{promptLine("u1"), assistantLine(0, 0, 0, 20000), assistantLine(0, 0, 0, 30000)}
+	if got := collect(turn1...); got != "15% · 30.0K tokens" {
+		t.Errorf("first turn has no baseline, got %q", got)
+	}
+
+	turn2 := append(turn1, promptLine("u2"), assistantLine(0, 0, 0, 32300))
+	if got := collect(turn2...); got != "16.2% · 32.3K tokens (+2.3K tok)" {
+		t.Errorf("got %q", got)
+	}
+	// Re-rendering within the same turn keeps the turn's baseline.
+	turn2 = append(turn2, assistantLine(0, 0, 0, 33000))
+	if got := collect(turn2...); got != "16.5% · 33.0K tokens (+3.0K tok)" {
+		t.Errorf("got %q", got)
+	}
+
+	// Other sessions do not share state.
+	other := seg.Collect(&config.InputData{SessionID: "s2", Model: config.ModelInfo{ID: "claude-sonnet-4"}, TranscriptPath: path})
+	if other.Primary != "16.5% · 33.0K tokens" {
+		t.Errorf("got %q", other.Primary)
+	}
+}
+
+func TestCostDelta(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	seg := &segment.CostSegment{Options: config.CostOptions{Source: config.CostSourceInput, ShowDelta: true}}
+	collect := func(cost float64, lines ...string) string {
+		os.WriteFile(path, []byte(joinLines(lines)), 0644)
+		return seg.Collect(&config.InputData{SessionID: "s1", TranscriptPath: path, Cost: config.CostInfo{TotalCostUSD: cost}}).Primary
+	}
+
+	if got := collect(1.00, promptLine("u1")); got != "$1.00" {
+		t.Errorf("got %q", got)
+	}
+	if got := collect(1.04, promptLine("u1"), promptLine("u2")); got != "$1.04 (+$0.04)" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSessionStateConcurrentAndPruned(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := writeTranscript(t, promptLine("u1"), assistantLine(0, 0, 0, 1000))
+
+	// A stale session from long ago is pruned on the next write.
+	os.MkdirAll(config.CacheDir(), 0755)
+	os.WriteFile(filepath.Join(config.CacheDir(), "session-state.json"),
+		[]byte(`{"stale":{"updated_at":1,"turn":"x","last":{"cost":1}}}`), 0644)
+
+	seg := &segment.ContextWindowSegment{Options: config.ContextWindowOptions{ShowDelta: true}}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			seg.Collect(&config.InputData{SessionID: fmt.Sprintf("s%d", i), TranscriptPath: path})
+		}(i)
+	}
+	wg.Wait()
+
+	states := readSessionStates(t)
+	if len(states) != 16 {
+		t.Errorf("expected 16 sessions after concurrent renders, got %d", len(states))
+	}
+	if _, ok := states["stale"]; ok {
+		t.Error("stale session should have been pruned")
+	}
+}
+
+func joinLines(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// sidechainLine builds a subagent assistant entry with the given usage
+func sidechainLine(id string, input, output int) string {
+	return fmt.Sprintf(`{"type":"assistant","isSidechain":true,"requestId":"req_%s","message":{"id":%q,"model":"claude-sonnet-4-20250514","role":"assistant","usage":{"input_tokens":%d,"output_tokens":%d,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}}}`,
+		id, id, input, output)
+}
+
+const sidechainPromptLine = `{"type":"user","isSidechain":true,"message":{"role":"user","content":"Search the codebase for TODOs"}}`
+
+func subagentTranscript(t *testing.T) string {
+	return writeTranscript(t,
+		userLine,
+		assistantLine(100, 200, 900, 30000),
+		toolUseLine("task1", "Task", map[string]string{"subagent_type": "general-purpose", "prompt": "Search"}),
+		toolUseLine("task2", "Task", map[string]string{"subagent_type": "Explore", "prompt": "Explore"}),
+		sidechainPromptLine,
+		sidechainLine("msg_a", 1000, 2000),
+		// The same message written twice while streaming
+		sidechainLine("msg_a", 1000, 2000),
+		sidechainLine("msg_b", 500000, 1000),
+		toolResultFor("task1", false),
+	)
+}
+
+func TestSubagentsSegment(t *testing.T) {
+	data := (&segment.SubagentsSegment{}).Collect(&config.InputData{TranscriptPath: subagentTranscript(t)})
+
+	if data.Primary != "1 running · 1 done · 504.0K tok · $1.55" {
+		t.Errorf("got %q", data.Primary)
+	}
+	if data.Metadata["tokens"] != "504000" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestSubagentsSegmentNoSubagents(t *testing.T) {
+	data := (&segment.SubagentsSegment{}).Collect(&config.InputData{
+		TranscriptPath: writeTranscript(t, userLine, assistantLine(1, 2, 0, 0)),
+	})
+	if data.Primary != "" {
+		t.Errorf("expected hidden segment, got %q", data.Primary)
+	}
+}
+
+func TestContextWindowIgnoresSidechain(t *testing.T) {
+	data := collectContextWindow(subagentTranscript(t), config.DefaultContextWindowOptions())
+	if data.Metadata["tokens"] != "31200" {
+		t.Errorf("sidechain usage leaked into context: %v", data.Metadata)
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// thinkingLine builds an assistant entry with a thinking block of the given text
+func thinkingLine(text string) string {
+	return fmt.Sprintf(`{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":%q,"signature":"sig"}]}}`, text)
+}
+
+const redactedThinkingLine = `{"type":"assistant","message":{"role":"assistant","content":[{"type":"redacted_thinking","data":"EmwKAhgBEgy3va3pzix"}]}}`
+
+func collectThinking(path string) segment.SegmentData {
+	return (&segment.ThinkingSegment{}).Collect(&config.InputData{TranscriptPath: path})
+}
+
+func TestThinkingSegmentEstimatesLastTurn(t *testing.T) {
+	path := writeTranscript(t,
+		userLine,
+		thinkingLine(strings.Repeat("a", 40000)),
+		userLine,
+		thinkingLine(strings.Repeat("b", 4000)),
+		toolUseLine("t1", "Read", map[string]string{"file_path": "/repo/main.go"}),
+		toolResultFor("t1", false),
+		thinkingLine(strings.Repeat("c", 799)),
+	)
+
+	data := collectThinking(path)
+	if data.Primary != "~1.2K tok" {
+		t.Errorf("got %q", data.Primary)
+	}
+	if data.Metadata["blocks"] != "2" || data.Metadata["tokens"] != "1200" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestThinkingSegmentRedacted(t *testing.T) {
+	if got := collectThinking(writeTranscript(t, userLine, redactedThinkingLine)).Primary; got != "on" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestThinkingSegmentHiddenWhenOff(t *testing.T) {
+	path := writeTranscript(t, userLine, thinkingLine("earlier turn"), userLine, assistantLine(1, 2, 0, 0))
+	if got := collectThinking(path).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
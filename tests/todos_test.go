@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/render"
+	"github.com/WAY29/cchline/segment"
+)
+
+// todoWriteLine builds an assistant entry calling TodoWrite with the given [content, status, activeForm] items
+func todoWriteLine(todos ...[3]string) string {
+	items := make([]map[string]string, len(todos))
+	for i, todo := range todos {
+		items[i] = map[string]string{"content": todo[0], "status": todo[1], "activeForm": todo[2]}
+	}
+	input, _ := json.Marshal(map[string]any{"todos": items})
+	return fmt.Sprintf(`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Updating todos"},{"type":"tool_use","id":"toolu_1","name":"TodoWrite","input":%s}]}}`, input)
+}
+
+func collectTodos(path string, maxWidth int) segment.SegmentData {
+	return (&segment.TodosSegment{Options: config.TodosOptions{MaxWidth: maxWidth}}).Collect(&config.InputData{
+		TranscriptPath: path,
+	})
+}
+
+func TestTodosSegmentUsesLatestTodoWrite(t *testing.T) {
+	path := writeTranscript(t,
+		userLine,
+		todoWriteLine(
+			[3]string{"Write tests", "in_progress", "Writing tests"},
+			[3]string{"Run tests", "pending", "Running tests"},
+		),
+		assistantLine(100, 200, 0, 0),
+		todoWriteLine(
+			[3]string{"Write tests", "completed", "Writing tests"},
+			[3]string{"Run tests", "in_progress", "Running tests"},
+			[3]string{"Fix lint", "pending", "Fixing lint"},
+		),
+	)
+
+	data := collectTodos(path, 30)
+	if data.Primary != "1/3 ✓ · Running tests" {
+		t.Errorf("got %q", data.Primary)
+	}
+	if data.Metadata["active"] != "Running tests" || data.Metadata["total"] != "3" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestTodosSegmentIgnoresSidechain(t *testing.T) {
+	sidechain := strings.Replace(todoWriteLine(
+		[3]string{"Scan files", "in_progress", "Scanning files"},
+	), `{"type":"assistant",`, `{"type":"assistant","isSidechain":true,`, 1)
+	path := writeTranscript(t,
+		userLine,
+		todoWriteLine(
+			[3]string{"Write tests", "in_progress", "Writing tests"},
+			[3]string{"Run tests", "pending", "Running tests"},
+		),
+		sidechain,
+	)
+
+	if got := collectTodos(path, 30).Primary; got != "0/2 ✓ · Writing tests" {
+		t.Errorf("subagent todos should be ignored, got %q", got)
+	}
+}
+
+func TestTodosSegmentTruncatesActiveForm(t *testing.T) {
+	path := writeTranscript(t, todoWriteLine(
+		[3]string{"Refactor", "in_progress", "Refactoring the transcript parser"},
+		[3]string{"Ship", "pending", "Shipping"},
+	))
+
+	if got := collectTodos(path, 12).Primary; got != "0/2 ✓ · Refactoring…" {
+		t.Errorf("got %q", got)
+	}
+	if got := collectTodos(path, 0).Primary; got != "0/2 ✓" {
+		t.Errorf("got %q with max_width 0", got)
+	}
+}
+
+func TestTodosSegmentHiddenWhenComplete(t *testing.T) {
+	path := writeTranscript(t, todoWriteLine(
+		[3]string{"Write tests", "completed", "Writing tests"},
+		[3]string{"Run tests", "completed", "Running tests"},
+	))
+	if got := collectTodos(path, 30).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+
+	if got := collectTodos(writeTranscript(t, userLine, assistantLine(1, 2, 0, 0)), 30).Primary; got != "" {
+		t.Errorf("expected hidden segment without todos, got %q", got)
+	}
+}
+
+func TestTodosProgressBarColorsRemainingWork(t *testing.T) {
+	todos := make([][3]string, 10)
+	for i := range todos {
+		todos[i] = [3]string{fmt.Sprintf("Step %d", i), "completed", ""}
+	}
+	todos[9][1] = "in_progress"
+	data := collectTodos(writeTranscript(t, userLine, todoWriteLine(todos...)), 0)
+	if data.Metadata["percent"] != "90.0" {
+		t.Fatalf("unexpected metadata %v", data.Metadata)
+	}
+
+	cfg := createTestConfig(config.ThemeModeDefault, " | ")
+	cfg.ProgressBar = config.DefaultProgressBarOptions()
+	cfg.ProgressBar.Segments = []string{string(config.SegmentTodos)}
+	opts := cfg.ProgressBar
+	got := render.NewStatusLineGenerator(cfg).Generate([]segment.SegmentResult{{ID: config.SegmentTodos, Data: data}})
+
+	// A list that is 90% done has little work left and is colored green, not critical.
+	green := config.ApplyColor(render.ProgressBar(90, opts), config.PercentColor(10, opts.WarnThreshold, opts.CriticalThreshold))
+	if !strings.Contains(got, green) {
+		t.Errorf("expected a green bar, got %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// toolUseLine builds an assistant entry calling a tool
+func toolUseLine(id, name string, input map[string]string) string {
+	params, _ := json.Marshal(input)
+	return fmt.Sprintf(`{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":%q,"name":%q,"input":%s}]}}`, id, name, params)
+}
+
+// toolResultFor builds a user entry carrying the result of a tool call
+func toolResultFor(id string, isError bool) string {
+	return fmt.Sprintf(`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":%q,"content":"ok","is_error":%t}]}}`, id, isError)
+}
+
+func toolsTranscript(t *testing.T) string {
+	return writeTranscript(t,
+		userLine,
+		toolUseLine("t1", "Read", map[string]string{"file_path": "/repo/go.mod"}),
+		toolResultFor("t1", false),
+		toolUseLine("t2", "Bash", map[string]string{"command": "go test ./... -run Foo"}),
+		toolResultFor("t2", true),
+		toolUseLine("t3", "Edit", map[string]string{"file_path": "/repo/main.go"}),
+		toolResultFor("t3", false),
+		toolUseLine("t4", "Bash", map[string]string{"command": "go vet ./..."}),
+		toolResultFor("t4", false),
+		// The same call written twice while streaming
+		toolUseLine("t5", "Edit", map[string]string{"file_path": "/repo/cmd/api.go"}),
+		toolUseLine("t5", "Edit", map[string]string{"file_path": "/repo/cmd/api.go"}),
+		toolResultFor("t5", true),
+	)
+}
+
+func TestToolsSegment(t *testing.T) {
+	data := (&segment.ToolsSegment{Options: config.DefaultToolsOptions()}).Collect(&config.InputData{
+		TranscriptPath: toolsTranscript(t),
+	})
+
+	if data.Primary != "Edit api.go · Bash×2 Edit×2 Read×1 · ✗2" {
+		t.Errorf("got %q", data.Primary)
+	}
+	if data.Metadata["total"] != "5" || data.Metadata["count_Bash"] != "2" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestToolsSegmentFilterAndAbbreviations(t *testing.T) {
+	opts := config.ToolsOptions{
+		Tools:         []string{"Bash", "Read"},
+		Abbreviations: map[string]string{"Bash": "$"},
+		MaxCounts:     1,
+		TargetWidth:   6,
+	}
+	data := (&segment.ToolsSegment{Options: opts}).Collect(&config.InputData{
+		TranscriptPath: toolsTranscript(t),
+	})
+
+	if data.Primary != "$ go vet · $×2 · ✗1" {
+		t.Errorf("got %q", data.Primary)
+	}
+}
+
+func TestToolsSegmentFreeTextTarget(t *testing.T) {
+	opts := config.DefaultToolsOptions()
+	opts.TargetWidth = 0
+	collect := func(name string, input map[string]string) string {
+		return (&segment.ToolsSegment{Options: opts}).Collect(&config.InputData{
+			TranscriptPath: writeTranscript(t, userLine, toolUseLine("t1", name, input)),
+		}).Primary
+	}
+
+	// Multi-line patterns are collapsed to a single line.
+	if got := collect("Grep", map[string]string{"pattern": "func main\n  foo"}); got != "Grep func main foo · Grep×1" {
+		t.Errorf("got %q", got)
+	}
+
+	// Long queries and descriptions are truncated even without target_width.
+	long := strings.Repeat("word ", 20)
+	want := "WebSearch " + strings.Repeat("word ", 7) + "word… · WebSearch×1"
+	if got := collect("WebSearch", map[string]string{"query": long}); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestToolsSegmentNoTools(t *testing.T) {
+	data := (&segment.ToolsSegment{Options: config.DefaultToolsOptions()}).Collect(&config.InputData{
+		TranscriptPath: writeTranscript(t, userLine, assistantLine(1, 2, 0, 0)),
+	})
+	if data.Primary != "" {
+		t.Errorf("expected hidden segment, got %q", data.Primary)
+	}
+}
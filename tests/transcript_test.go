@@ -0,0 +1,244 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+// writeTranscript writes JSONL transcript lines into a temporary project directory
+func writeTranscript(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// assistantLine builds an assistant transcript entry with the given usage
+func assistantLine(input, output, cacheCreation, cacheRead int) string {
+	return fmt.Sprintf(`{"type":"assistant","message":{"role":"assistant","usage":{"input_tokens":%d,"output_tokens":%d,"cache_creation_input_tokens":%d,"cache_read_input_tokens":%d}}}`,
+		input, output, cacheCreation, cacheRead)
+}
+
+const (
+	userLine            = `{"type":"user","message":{"role":"user","content":"hello"}}`
+	compactBoundaryLine = `{"type":"system","subtype":"compact_boundary","content":"Conversation compacted","compactMetadata":{"trigger":"auto","preTokens":160000}}`
+	compactSummaryLine  = `{"type":"user","isCompactSummary":true,"message":{"role":"user","content":"This session is being continued..."}}`
+)
+
+func collectContextWindow(path string, opts config.ContextWindowOptions) segment.SegmentData {
+	return (&segment.ContextWindowSegment{Options: opts}).Collect(&config.InputData{
+		Model:          config.ModelInfo{ID: "claude-sonnet-4-20250514"},
+		TranscriptPath: path,
+	})
+}
+
+func TestContextWindowSegmentUsage(t *testing.T) {
+	path := writeTranscript(t, userLine, assistantLine(100, 200, 900, 30000))
+
+	data := collectContextWindow(path, config.DefaultContextWindowOptions())
+	if data.Primary != "15.6% · 31.2K tokens" {
+		t.Errorf("got %q, want %q", data.Primary, "15.6% · 31.2K tokens")
+	}
+	if data.Metadata["tokens"] != "31200" || data.Metadata["percent"] != "15.6" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+	if data.Metadata["compact_threshold"] != "160000" || data.Metadata["remaining"] != "128800" {
+		t.Errorf("unexpected compaction metadata %v", data.Metadata)
+	}
+}
+
+func TestContextWindowSegmentNoData(t *testing.T) {
+	data := collectContextWindow(writeTranscript(t, userLine), config.DefaultContextWindowOptions())
+	if data.Primary != "- · - tokens" {
+		t.Errorf("got %q, want %q", data.Primary, "- · - tokens")
+	}
+}
+
+func TestContextWindowSegmentCompactAware(t *testing.T) {
+	path := writeTranscript(t, userLine, assistantLine(0, 800, 4000, 120000))
+
+	data := collectContextWindow(path, config.ContextWindowOptions{CompactAware: true, AutoCompactThreshold: 80})
+	if data.Primary != "78% · 124.8K tokens · 35.2K left" {
+		t.Errorf("got %q", data.Primary)
+	}
+	if data.Metadata["percent"] != "78.0" || data.Metadata["limit_percent"] != "62.4" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+
+	// Out of range thresholds fall back to the default.
+	data = collectContextWindow(path, config.ContextWindowOptions{CompactAware: true, AutoCompactThreshold: 150})
+	if data.Metadata["compact_threshold"] != "160000" {
+		t.Errorf("expected default threshold, got %v", data.Metadata)
+	}
+}
+
+func TestContextWindowSegmentResetsAfterCompaction(t *testing.T) {
+	path := writeTranscript(t,
+		userLine,
+		assistantLine(0, 1000, 2000, 150000),
+		compactBoundaryLine,
+		compactSummaryLine,
+	)
+
+	data := collectContextWindow(path, config.DefaultContextWindowOptions())
+	if data.Primary != "0% · 0 tokens" {
+		t.Errorf("expected reset after compaction, got %q", data.Primary)
+	}
+	if data.Metadata["compactions"] != "1" {
+		t.Errorf("expected one compaction, got %v", data.Metadata)
+	}
+
+	path = writeTranscript(t,
+		userLine,
+		assistantLine(0, 1000, 2000, 150000),
+		compactBoundaryLine,
+		compactSummaryLine,
+		assistantLine(0, 500, 9500, 10000),
+		compactSummaryLine,
+		assistantLine(0, 500, 1500, 8000),
+	)
+	data = collectContextWindow(path, config.DefaultContextWindowOptions())
+	if data.Primary != "5% · 10.0K tokens" {
+		t.Errorf("expected usage after compaction, got %q", data.Primary)
+	}
+	if data.Metadata["compactions"] != "2" {
+		t.Errorf("expected two compactions, got %v", data.Metadata)
+	}
+}
+
+// timedLine adds a timestamp to a transcript entry
+func timedLine(line string, at time.Time) string {
+	return strings.Replace(line, "{", fmt.Sprintf(`{"timestamp":%q,`, at.UTC().Format(time.RFC3339Nano)), 1)
+}
+
+const toolResultLine = `{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"ok"}]}}`
+
+// etaTranscript builds one turn per context size, two minutes apart, each with a tool round trip
+func etaTranscript(t *testing.T, contexts ...int) []string {
+	t.Helper()
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	var lines []string
+	for i, tokens := range contexts {
+		at := start.Add(time.Duration(i) * 2 * time.Minute)
+		lines = append(lines,
+			timedLine(userLine, at),
+			timedLine(assistantLine(10, 10, 0, tokens/2), at.Add(10*time.Second)),
+			timedLine(toolResultLine, at.Add(20*time.Second)),
+			timedLine(assistantLine(10, 10, 0, tokens-20), at.Add(30*time.Second)),
+		)
+	}
+	return lines
+}
+
+func collectContextEta(path string, window int) segment.SegmentData {
+	return (&segment.ContextEtaSegment{
+		Options:       config.ContextEtaOptions{Window: window},
+		ContextWindow: config.DefaultContextWindowOptions(),
+	}).Collect(&config.InputData{
+		Model:          config.ModelInfo{ID: "claude-sonnet-4-20250514"},
+		TranscriptPath: path,
+	})
+}
+
+func TestContextEtaSegment(t *testing.T) {
+	path := writeTranscript(t, etaTranscript(t, 20000, 30000, 40000, 60000)...)
+
+	// 40K over 3 turns and 6 minutes, 100K left before the 160K threshold.
+	data := collectContextEta(path, 5)
+	if data.Primary != "~8 turns / 15m to compact" {
+		t.Errorf("got %q, want %q", data.Primary, "~8 turns / 15m to compact")
+	}
+	if data.Metadata["tokens_per_turn"] != "13333" || data.Metadata["remaining"] != "100000" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+
+	// A one-turn window only looks at the latest 20K jump.
+	if got := collectContextEta(path, 1).Primary; got != "~5 turns / 10m to compact" {
+		t.Errorf("window 1: got %q", got)
+	}
+}
+
+func TestContextEtaSegmentNeedsHistory(t *testing.T) {
+	if got := collectContextEta(writeTranscript(t, etaTranscript(t, 20000)...), 5).Primary; got != "" {
+		t.Errorf("a single turn is not enough to estimate, got %q", got)
+	}
+
+	lines := append(etaTranscript(t, 20000, 30000, 40000), compactBoundaryLine, compactSummaryLine)
+	if got := collectContextEta(writeTranscript(t, lines...), 5).Primary; got != "" {
+		t.Errorf("history before compaction should be discarded, got %q", got)
+	}
+
+	// Without timestamps only the turn estimate is shown.
+	path := writeTranscript(t, userLine, assistantLine(0, 0, 0, 20000), userLine, assistantLine(0, 0, 0, 30000))
+	if got := collectContextEta(path, 5).Primary; got != "~13 turns to compact" {
+		t.Errorf("got %q, want %q", got, "~13 turns to compact")
+	}
+}
+
+func collectCache(path string) segment.SegmentData {
+	return (&segment.CacheSegment{}).Collect(&config.InputData{TranscriptPath: path})
+}
+
+func TestCacheSegment(t *testing.T) {
+	now := time.Now()
+	lines := []string{
+		timedLine(userLine, now.Add(-20*time.Minute)),
+		timedLine(assistantLine(10, 100, 50000, 0), now.Add(-19*time.Minute)),
+		timedLine(userLine, now.Add(-3*time.Minute)),
+		timedLine(assistantLine(10, 100, 1000, 50000), now.Add(-2*time.Minute)),
+		timedLine(toolResultLine, now.Add(-2*time.Minute)),
+		timedLine(assistantLine(10, 100, 500, 51000), now.Add(-108*time.Second)),
+	}
+
+	// Last turn: 101K read of 102.52K prompt tokens; session: 101K of 152.53K.
+	data := collectCache(writeTranscript(t, lines...))
+	if !strings.HasPrefix(data.Primary, "99% (66%) · warm 3:") {
+		t.Errorf("got %q, want 99%% (66%%) · warm 3:xx", data.Primary)
+	}
+	if data.Metadata["hit_ratio"] != "98.5" || data.Metadata["session_hit_ratio"] != "66.2" || data.Metadata["percent_scale"] != "higher_is_better" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+
+	// The cache goes cold five minutes after the last assistant message.
+	lines = append(lines[:4], timedLine(assistantLine(10, 100, 500, 51000), now.Add(-6*time.Minute)))
+	if got := collectCache(writeTranscript(t, lines...)).Primary; !strings.HasSuffix(got, " · cold") {
+		t.Errorf("expected a cold cache, got %q", got)
+	}
+}
+
+func TestCacheSegmentIgnoresSidechain(t *testing.T) {
+	now := time.Now()
+	path := writeTranscript(t,
+		timedLine(userLine, now.Add(-3*time.Minute)),
+		timedLine(assistantLine(10, 100, 0, 1000), now.Add(-2*time.Minute)),
+		// Subagent turns use their own cache and must not affect the ratio or the TTL.
+		timedLine(sidechainLine("msg_side", 9000, 100), now.Add(-10*time.Second)),
+	)
+	data := collectCache(path)
+	if !strings.HasPrefix(data.Primary, "99% (99%) · warm 2:") {
+		t.Errorf("got %q, want 99%% (99%%) · warm 2:xx", data.Primary)
+	}
+}
+
+func TestCacheSegmentLongTTL(t *testing.T) {
+	line := `{"type":"assistant","message":{"role":"assistant","usage":{"input_tokens":10,"output_tokens":10,"cache_creation_input_tokens":1000,"cache_read_input_tokens":0,"cache_creation":{"ephemeral_5m_input_tokens":0,"ephemeral_1h_input_tokens":1000}}}}`
+	path := writeTranscript(t, userLine, timedLine(line, time.Now().Add(-10*time.Minute)))
+	if got := collectCache(path).Primary; !strings.Contains(got, "warm 49:") && !strings.Contains(got, "warm 50:") {
+		t.Errorf("1h cache writes should extend the countdown, got %q", got)
+	}
+}
+
+func TestCacheSegmentNoUsage(t *testing.T) {
+	if got := collectCache(writeTranscript(t, userLine)).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/WAY29/cchline/config"
+	"github.com/WAY29/cchline/segment"
+)
+
+const metaLine = `{"type":"user","isMeta":true,"message":{"role":"user","content":"Caveat: The messages below were generated by the user while running local commands."}}`
+
+func collectTurns(path string) segment.SegmentData {
+	return (&segment.TurnsSegment{}).Collect(&config.InputData{TranscriptPath: path})
+}
+
+func TestTurnsSegment(t *testing.T) {
+	path := writeTranscript(t,
+		metaLine,
+		userLine,
+		pricedAssistantLine("claude-sonnet-4", "msg_1", "req_1", `{"input_tokens":1,"output_tokens":1}`),
+		// Second content block of the same reply
+		pricedAssistantLine("claude-sonnet-4", "msg_1", "req_1", `{"input_tokens":1,"output_tokens":2}`),
+		toolResultFor("t1", false),
+		pricedAssistantLine("claude-sonnet-4", "msg_2", "req_2", `{"input_tokens":1,"output_tokens":1}`),
+		compactBoundaryLine,
+		compactSummaryLine,
+		userLine,
+		sidechainPromptLine,
+		sidechainLine("msg_s", 10, 10),
+		pricedAssistantLine("claude-sonnet-4", "msg_3", "req_3", `{"input_tokens":1,"output_tokens":1}`),
+	)
+
+	data := collectTurns(path)
+	if want := "T2 · 6 msgs · 1×compact"; data.Primary != want {
+		t.Errorf("got %q, want %q", data.Primary, want)
+	}
+	if data.Metadata["replies"] != "3" || data.Metadata["tool_results"] != "1" {
+		t.Errorf("unexpected metadata %v", data.Metadata)
+	}
+}
+
+func TestTurnsSegmentWithoutCompaction(t *testing.T) {
+	if got := collectTurns(writeTranscript(t, userLine, assistantLine(1, 2, 0, 0))).Primary; got != "T1 · 2 msgs" {
+		t.Errorf("got %q", got)
+	}
+	if got := collectTurns(writeTranscript(t, metaLine)).Primary; got != "" {
+		t.Errorf("expected hidden segment, got %q", got)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -223,13 +222,35 @@ func (m *Model) moveCursorVertical(delta int) {
 
 var segmentCycle = []string{
 	"model",
+	"thinking",
 	"directory",
 	"git",
+	"git_diff",
 	"context_window",
+	"context_eta",
+	"cache",
 	"usage",
 	"cost",
+	"daily_usage",
+	"weekly_usage",
+	"monthly_usage",
+	"block",
+	"api_errors",
 	"session",
+	"session_title",
+	"turns",
+	"todos",
+	"tools",
+	"files",
+	"subagents",
+	"mcp",
 	"output_style",
+	"python_env",
+	"go_version",
+	"node_version",
+	"kube_context",
+	"cloud_profile",
+	"cc_version",
 	"update",
 	"cch_model",
 	"cch_provider",
@@ -678,11 +699,6 @@ func getExecutablePath() (string, error) {
 	return filepath.EvalSymlinks(exe)
 }
 
-// getSettingsPath 获取 Claude settings.json 路径
-func getSettingsPath() string {
-	return filepath.Join(os.Getenv("HOME"), ".claude", "settings.json")
-}
-
 // installStatusLine 安装 statusLine 到 settings.json
 func (m *Model) installStatusLine() error {
 	exePath, err := getExecutablePath()
@@ -690,7 +706,7 @@ func (m *Model) installStatusLine() error {
 		return fmt.Errorf("获取可执行文件路径失败: %w", err)
 	}
 
-	settingsPath := getSettingsPath()
+	settingsPath := config.SettingsPath()
 
 	// 读取现有配置
 	var settings map[string]any
@@ -729,7 +745,7 @@ func (m *Model) installStatusLine() error {
 
 // uninstallStatusLine 从 settings.json 移除 statusLine
 func (m *Model) uninstallStatusLine() error {
-	settingsPath := getSettingsPath()
+	settingsPath := config.SettingsPath()
 
 	// 读取现有配置
 	data, err := os.ReadFile(settingsPath)
@@ -776,84 +792,30 @@ const (
 	installStatusUnknown
 )
 
-type semVer struct {
-	major int
-	minor int
-	patch int
-}
-
-func parseSemVerToken(token string) (semVer, bool) {
-	token = strings.TrimSpace(token)
-	token = strings.TrimPrefix(token, "v")
-	token = strings.SplitN(token, "-", 2)[0]
-	token = strings.SplitN(token, "+", 2)[0]
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		return semVer{}, false
-	}
-
-	major, err := strconv.Atoi(parts[0])
-	if err != nil || major < 0 {
-		return semVer{}, false
-	}
-	minor, err := strconv.Atoi(parts[1])
-	if err != nil || minor < 0 {
-		return semVer{}, false
-	}
-	patch, err := strconv.Atoi(parts[2])
-	if err != nil || patch < 0 {
-		return semVer{}, false
-	}
-
-	return semVer{major: major, minor: minor, patch: patch}, true
-}
-
-func compareSemVer(a, b semVer) int {
-	if a.major != b.major {
-		if a.major < b.major {
-			return -1
-		}
-		return 1
-	}
-	if a.minor != b.minor {
-		if a.minor < b.minor {
-			return -1
-		}
-		return 1
-	}
-	if a.patch != b.patch {
-		if a.patch < b.patch {
-			return -1
-		}
-		return 1
-	}
-	return 0
-}
-
-func parseCCHLineVersionOutput(out []byte) (isDev bool, ver semVer, ok bool) {
+func parseCCHLineVersionOutput(out []byte) (isDev bool, ver config.SemVer, ok bool) {
 	s := strings.TrimSpace(string(out))
 	if s == "" {
-		return false, semVer{}, false
+		return false, config.SemVer{}, false
 	}
 
 	fields := strings.Fields(s)
 	for _, f := range fields {
 		if f == "dev" {
-			return true, semVer{}, true
+			return true, config.SemVer{}, true
 		}
 	}
 
 	for i := len(fields) - 1; i >= 0; i-- {
-		if v, ok := parseSemVerToken(fields[i]); ok {
+		if v, ok := config.ParseSemVer(fields[i]); ok {
 			return false, v, true
 		}
 	}
 
-	return false, semVer{}, false
+	return false, config.SemVer{}, false
 }
 
 func readStatusLineCommandFromSettings() (string, error) {
-	settingsPath := getSettingsPath()
+	settingsPath := config.SettingsPath()
 	data, err := os.ReadFile(settingsPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -882,7 +844,7 @@ func readStatusLineCommandFromSettings() (string, error) {
 	return command, nil
 }
 
-func getInstallStatus() (installStatus, *semVer, *semVer) {
+func getInstallStatus() (installStatus, *config.SemVer, *config.SemVer) {
 	command, err := readStatusLineCommandFromSettings()
 	if err != nil {
 		if errors.Is(err, errStatusLineNotInstalled) {
@@ -914,7 +876,7 @@ func getInstallStatus() (installStatus, *semVer, *semVer) {
 		return installStatusUnknown, nil, nil
 	}
 
-	if compareSemVer(installedSem, currentSem) < 0 {
+	if config.CompareSemVer(installedSem, currentSem) < 0 {
 		return installStatusOutdated, &installedSem, &currentSem
 	}
 	return installStatusInstalled, &installedSem, &currentSem
@@ -15,8 +15,8 @@ import (
 
 type installStatusMsg struct {
 	status       installStatus
-	installedVer *semVer
-	currentVer   *semVer
+	installedVer *config.SemVer
+	currentVer   *config.SemVer
 }
 
 func fetchInstallStatusCmd() tea.Cmd {
@@ -146,8 +146,8 @@ type Model struct {
 
 	installStatusLoading bool
 	installStatusValue   installStatus
-	installInstalledVer  *semVer
-	installCurrentVer    *semVer
+	installInstalledVer  *config.SemVer
+	installCurrentVer    *config.SemVer
 }
 
 // NewModel 创建新的 TUI 模型
@@ -652,12 +652,12 @@ func (m Model) buildLayout(width, height int) layout {
 			case installStatusInstalled:
 				installStatus = "  " + enabledStyle.Render("● 已安装")
 				if m.installInstalledVer != nil && m.installCurrentVer != nil {
-					installStatus += " " + disabledStyle.Render(fmt.Sprintf("(installed %d.%d.%d, current %d.%d.%d)", m.installInstalledVer.major, m.installInstalledVer.minor, m.installInstalledVer.patch, m.installCurrentVer.major, m.installCurrentVer.minor, m.installCurrentVer.patch))
+					installStatus += " " + disabledStyle.Render(fmt.Sprintf("(installed %s, current %s)", m.installInstalledVer, m.installCurrentVer))
 				}
 			case installStatusOutdated:
 				installStatus = "  " + valueStyle.Render("◐ 版本过旧")
 				if m.installInstalledVer != nil && m.installCurrentVer != nil {
-					installStatus += " " + disabledStyle.Render(fmt.Sprintf("(installed %d.%d.%d < current %d.%d.%d)", m.installInstalledVer.major, m.installInstalledVer.minor, m.installInstalledVer.patch, m.installCurrentVer.major, m.installCurrentVer.minor, m.installCurrentVer.patch))
+					installStatus += " " + disabledStyle.Render(fmt.Sprintf("(installed %s < current %s)", m.installInstalledVer, m.installCurrentVer))
 				}
 			case installStatusUnknown:
 				installStatus = "  " + valueStyle.Render("◑ 版本未知")
@@ -796,6 +796,34 @@ func (m Model) generatePreview() string {
 		"cch_cost":       "$1.50/$10",
 		"cch_requests":   "123 reqs",
 		"cch_limits":     "5h:$0",
+		"git_diff":       "3c +210/−34 (7f)",
+		"context_eta":    "~6 turns / 14m to compact",
+		"cache":          "87% (92%) · warm 3:12",
+		"daily_usage":    "$12.34 · 8.1M tok",
+		"weekly_usage":   "$58.20 · 41.3M tok",
+		"monthly_usage":  "$214.90 · 152.7M tok",
+		"block":          "$3.21 · 1.2M tok · 1h50m (3h10m left) · ~$8.60",
+		"todos":          "3/7 ✓ · Running tests",
+		"tools":          "Edit main.go · Edit×5 Bash×3 Read×2 · ✗1",
+		"subagents":      "1 running · 2 done · 45.2K tok · $0.12",
+		"session_title":  "Fix login redirect loop",
+		"files":          "5 files (2 dirty) · cmd/api.go*",
+		"turns":          "T14 · 62 msgs · 1×compact",
+		"thinking":       "~1.2K tok",
+		"api_errors":     "2×429 · 3m ago",
+		"mcp":            "3 servers · used: github, linear",
+		"cc_version":     "1.0.80 ⚠ < 1.0.90",
+		"python_env":     "api 3.12.1",
+		"go_version":     "1.25.3",
+		"node_version":   "v20.11.0",
+		"kube_context":   "prod-eu",
+		"cloud_profile":  "aws:prod · gcp:my-project",
+	}
+
+	// 带百分比的 segment 提供 percent 元数据，以便预览进度条
+	mockPercent := map[string]string{
+		"context_window": "15.6",
+		"cch_cost":       "15.0",
 	}
 
 	// 根据当前配置构建 SegmentResult 列表
@@ -828,9 +856,13 @@ func (m Model) generatePreview() string {
 		}
 
 		// 构建 SegmentResult
+		data := segment.SegmentData{Primary: mockValue}
+		if percent, ok := mockPercent[name]; ok {
+			data.Metadata = map[string]string{"percent": percent}
+		}
 		results = append(results, segment.SegmentResult{
 			ID:   config.SegmentID(name),
-			Data: segment.SegmentData{Primary: mockValue},
+			Data: data,
 		})
 	}
 
@@ -1,45 +1,24 @@
 package tui
 
-import "testing"
+import (
+	"testing"
 
-func TestParseSemVerToken(t *testing.T) {
-	cases := []struct {
-		in      string
-		wantOK  bool
-		wantVer semVer
-	}{
-		{"1.2.3", true, semVer{major: 1, minor: 2, patch: 3}},
-		{"v1.2.3", true, semVer{major: 1, minor: 2, patch: 3}},
-		{"1.2.3-beta.1", true, semVer{major: 1, minor: 2, patch: 3}},
-		{"1.2", false, semVer{}},
-		{"dev", false, semVer{}},
-		{"", false, semVer{}},
-	}
-
-	for _, tc := range cases {
-		got, ok := parseSemVerToken(tc.in)
-		if ok != tc.wantOK {
-			t.Fatalf("parseSemVerToken(%q) ok=%v want=%v", tc.in, ok, tc.wantOK)
-		}
-		if ok && got != tc.wantVer {
-			t.Fatalf("parseSemVerToken(%q)=%v want=%v", tc.in, got, tc.wantVer)
-		}
-	}
-}
+	"github.com/WAY29/cchline/config"
+)
 
 func TestParseCCHLineVersionOutput(t *testing.T) {
 	cases := []struct {
 		in       string
 		wantDev  bool
 		wantOK   bool
-		wantSem  semVer
+		wantSem  config.SemVer
 		semverOK bool
 	}{
-		{"cchline dev\n", true, true, semVer{}, false},
-		{"cchline 1.2.3\n", false, true, semVer{major: 1, minor: 2, patch: 3}, true},
-		{"something cchline 2.0.1", false, true, semVer{major: 2, minor: 0, patch: 1}, true},
-		{"", false, false, semVer{}, false},
-		{"cchline ???", false, false, semVer{}, false},
+		{"cchline dev\n", true, true, config.SemVer{}, false},
+		{"cchline 1.2.3\n", false, true, config.SemVer{Major: 1, Minor: 2, Patch: 3}, true},
+		{"something cchline 2.0.1", false, true, config.SemVer{Major: 2, Minor: 0, Patch: 1}, true},
+		{"", false, false, config.SemVer{}, false},
+		{"cchline ???", false, false, config.SemVer{}, false},
 	}
 
 	for _, tc := range cases {
@@ -54,15 +33,3 @@ func TestParseCCHLineVersionOutput(t *testing.T) {
 		}
 	}
 }
-
-func TestCompareSemVer(t *testing.T) {
-	if compareSemVer(semVer{1, 0, 0}, semVer{1, 0, 0}) != 0 {
-		t.Fatalf("expected equal")
-	}
-	if compareSemVer(semVer{1, 0, 0}, semVer{1, 0, 1}) >= 0 {
-		t.Fatalf("expected less")
-	}
-	if compareSemVer(semVer{2, 0, 0}, semVer{1, 9, 9}) <= 0 {
-		t.Fatalf("expected greater")
-	}
-}